@@ -0,0 +1,146 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Circuit breaker states, as reported by CircuitBreaker.State
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// CircuitOpenError is returned instead of calling a backend whose circuit breaker
+// is open. Its gRPC status is Unavailable.
+type CircuitOpenError struct {
+	Service    string
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s service is unavailable, circuit breaker open", e.Service)
+}
+
+// GRPCStatus lets status.Code report the error as Unavailable
+func (e *CircuitOpenError) GRPCStatus() *status.Status {
+	return status.New(codes.Unavailable, e.Error())
+}
+
+// CircuitBreaker stops calling a backend after it failed threshold consecutive
+// times, so requests fail fast instead of piling up on timeouts. After cooldown one
+// call is let through; its success closes the breaker and its failure opens it
+// again. It is safe for concurrent use.
+type CircuitBreaker struct {
+	service   string
+	threshold int
+	cooldown  time.Duration
+
+	mutex    sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreaker creates a closed breaker for service. A threshold of zero or
+// less disables it.
+func NewCircuitBreaker(service string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		service:   service,
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerClosed,
+	}
+}
+
+// State returns the breaker's current state
+func (b *CircuitBreaker) State() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// Interceptor fails calls with a CircuitOpenError while the breaker is open
+func (b *CircuitBreaker) Interceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if b.threshold <= 0 {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		if err := b.allow(); err != nil {
+			return err
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.record(ctx, err)
+		return err
+	}
+}
+
+// allow admits a call unless the breaker is open. Once the cooldown has passed a
+// single trial call is admitted, and the rest are refused until it completes.
+func (b *CircuitBreaker) allow() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case BreakerClosed:
+		return nil
+	case BreakerOpen:
+		if wait := b.cooldown - time.Since(b.openedAt); wait > 0 {
+			return &CircuitOpenError{Service: b.service, RetryAfter: wait}
+		}
+		b.state = BreakerHalfOpen
+		return nil
+	default:
+		// The trial call is still in flight
+		return &CircuitOpenError{Service: b.service, RetryAfter: b.cooldown}
+	}
+}
+
+// record updates the breaker with the outcome of an admitted call. Only failures
+// that show the backend is unhealthy count, including timeouts; application errors
+// such as NotFound are answers and count as successes.
+func (b *CircuitBreaker) record(ctx context.Context, err error) {
+	code := status.Code(err)
+	callerGaveUp := code == codes.Canceled || errors.Is(ctx.Err(), context.Canceled)
+	failed := code == codes.Unavailable || code == codes.ResourceExhausted || code == codes.DeadlineExceeded
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch {
+	case callerGaveUp:
+		// Says nothing about the backend. A trial call that ended this way leaves
+		// the breaker ready for the next one.
+		if b.state == BreakerHalfOpen {
+			b.state, b.openedAt = BreakerOpen, time.Now().Add(-b.cooldown)
+		}
+	case !failed:
+		if b.state != BreakerClosed {
+			logrus.WithField("service", b.service).Info("Circuit breaker closed, backend recovered")
+		}
+		b.state, b.failures = BreakerClosed, 0
+	default:
+		b.failures++
+		if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+			if b.state == BreakerClosed {
+				logrus.WithFields(logrus.Fields{
+					"service":  b.service,
+					"failures": b.failures,
+				}).WithError(err).Warn("Circuit breaker opened")
+			}
+			b.state, b.openedAt = BreakerOpen, time.Now()
+		}
+	}
+}
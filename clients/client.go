@@ -1,49 +1,74 @@
 package clients
 
 import (
+	"crypto/tls"
 	"errors"
+	"net"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 
 	config "github.com/liju-github/FoodBuddyAPIGateway/configs"
 )
 
 type ClientConnections struct {
-	ConnUser       *grpc.ClientConn
-	ConnRestaurant *grpc.ClientConn
-	ConnAdmin       *grpc.ClientConn
-	ConnOrderCart  *grpc.ClientConn
+	ConnUser       *ReconnectingConn
+	ConnRestaurant *ReconnectingConn
+	ConnAdmin      *ReconnectingConn
+	ConnOrderCart  *ReconnectingConn
+
+	breakers map[string]*CircuitBreaker
 }
 
 func InitClients(config *config.Config) (*ClientConnections, error) {
+	creds, err := transportCredentials(config)
+	if err != nil {
+		return nil, errors.New("could not load gRPC TLS credentials: " + err.Error())
+	}
+	// Each service has its own breaker, outside the retries so a retried call
+	// counts once
+	breakers := make(map[string]*CircuitBreaker)
+	dial := func(service, host, port string) (*ReconnectingConn, error) {
+		breaker := NewCircuitBreaker(service, config.GRPCBreakerFailures, config.GRPCBreakerCooldown)
+		breakers[service] = breaker
+		return NewReconnectingConn(net.JoinHostPort(host, port), config.GRPCReconnectInterval, config.GRPCReconnectFailures,
+			grpc.WithTransportCredentials(creds),
+			grpc.WithChainUnaryInterceptor(
+				breaker.Interceptor(),
+				RetryInterceptor(config.GRPCRetryMaxAttempts, config.GRPCRetryBackoff),
+			),
+		)
+	}
+
 	// User Service Connection
-	ConnUser, err := grpc.NewClient("localhost:"+config.UserGRPCPort, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	ConnUser, err := dial("user", config.UserGRPCHost, config.UserGRPCPort)
 	if err != nil {
 		return nil, errors.New("could not Connect to User gRPC server: " + err.Error())
 	}
 
 	// Restaurant Service Connection
-	ConnRestaurant, err := grpc.NewClient("localhost:"+config.RestaurantGRPCPort, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	ConnRestaurant, err := dial("restaurant", config.RestaurantGRPCHost, config.RestaurantGRPCPort)
 	if err != nil {
 		ConnUser.Close()
 		return nil, errors.New("could not Connect to Restaurant gRPC server: " + err.Error())
 	}
 
 	// Admin Service Connection
-	ConnAdmin, err := grpc.NewClient("localhost:"+config.AdminGRPCPort, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	ConnAdmin, err := dial("admin", config.AdminGRPCHost, config.AdminGRPCPort)
 	if err != nil {
-		ConnUser.Close() 
-		ConnRestaurant.Close() 
+		ConnUser.Close()
+		ConnRestaurant.Close()
 		return nil, errors.New("could not Connect to Admin gRPC server: " + err.Error())
 	}
 
 	// OrderCart Service Connection
-	ConnOrderCart, err := grpc.NewClient("localhost:"+config.OrderCartGRPCPort, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	ConnOrderCart, err := dial("orderCart", config.OrderCartGRPCHost, config.OrderCartGRPCPort)
 	if err != nil {
-		ConnUser.Close() 
-		ConnRestaurant.Close() 
-		return nil, errors.New("could not Connect to Admin gRPC server: " + err.Error())
+		ConnUser.Close()
+		ConnRestaurant.Close()
+		ConnAdmin.Close()
+		return nil, errors.New("could not Connect to OrderCart gRPC server: " + err.Error())
 	}
 
 	return &ClientConnections{
@@ -51,9 +76,43 @@ func InitClients(config *config.Config) (*ClientConnections, error) {
 		ConnRestaurant: ConnRestaurant,
 		ConnAdmin:      ConnAdmin,
 		ConnOrderCart:  ConnOrderCart,
+		breakers:       breakers,
 	}, nil
 }
 
+// transportCredentials returns TLS credentials when GRPCUseTLS is set, verified
+// against GRPCCACertPath if given and the system certificate pool otherwise. Plain
+// connections are only used when TLS is disabled, as in local development.
+func transportCredentials(config *config.Config) (credentials.TransportCredentials, error) {
+	if !config.GRPCUseTLS {
+		return insecure.NewCredentials(), nil
+	}
+	if config.GRPCCACertPath != "" {
+		return credentials.NewClientTLSFromFile(config.GRPCCACertPath, "")
+	}
+	// A nil RootCAs makes crypto/tls use the system pool
+	return credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12}), nil
+}
+
+// Services returns the backend connections by service name
+func (c *ClientConnections) Services() map[string]*ReconnectingConn {
+	return map[string]*ReconnectingConn{
+		"user":       c.ConnUser,
+		"restaurant": c.ConnRestaurant,
+		"orderCart":  c.ConnOrderCart,
+		"admin":      c.ConnAdmin,
+	}
+}
+
+// BreakerStates returns the circuit breaker state of each service by name
+func (c *ClientConnections) BreakerStates() map[string]string {
+	states := make(map[string]string, len(c.breakers))
+	for service, breaker := range c.breakers {
+		states[service] = breaker.State()
+	}
+	return states
+}
+
 func (c *ClientConnections) Close() {
 	if c.ConnUser != nil {
 		c.ConnUser.Close()
@@ -0,0 +1,115 @@
+package clients
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// ReconnectingConn is a gRPC connection that replaces itself when it stays in
+// transient failure. grpc.NewClient resolves the target when it connects, so a
+// backend whose address changed can otherwise keep failing on stale addresses.
+// Service clients built on it keep working across reconnects.
+type ReconnectingConn struct {
+	target string
+	opts   []grpc.DialOption
+
+	mu   sync.RWMutex
+	conn *grpc.ClientConn
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewReconnectingConn creates a connection to target and, when interval is
+// positive, checks its state every interval and recreates it after failures
+// consecutive checks found it in transient failure
+func NewReconnectingConn(target string, interval time.Duration, failures int, opts ...grpc.DialOption) (*ReconnectingConn, error) {
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	rc := &ReconnectingConn{
+		target: target,
+		opts:   opts,
+		conn:   conn,
+		stop:   make(chan struct{}),
+	}
+	if interval > 0 {
+		go rc.monitor(interval, max(failures, 1))
+	}
+	return rc, nil
+}
+
+// Invoke implements grpc.ClientConnInterface
+func (rc *ReconnectingConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	return rc.current().Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream implements grpc.ClientConnInterface
+func (rc *ReconnectingConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return rc.current().NewStream(ctx, desc, method, opts...)
+}
+
+// Close stops the state checks and closes the current connection
+func (rc *ReconnectingConn) Close() error {
+	rc.stopOnce.Do(func() { close(rc.stop) })
+	return rc.current().Close()
+}
+
+func (rc *ReconnectingConn) current() *grpc.ClientConn {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return rc.conn
+}
+
+func (rc *ReconnectingConn) monitor(interval time.Duration, failures int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	consecutive := 0
+	for {
+		select {
+		case <-rc.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if rc.current().GetState() != connectivity.TransientFailure {
+			consecutive = 0
+			continue
+		}
+		consecutive++
+		if consecutive >= failures {
+			rc.reconnect()
+			consecutive = 0
+		}
+	}
+}
+
+// reconnect swaps in a fresh connection and closes the failing one. Calls still
+// waiting on the old connection are cancelled.
+func (rc *ReconnectingConn) reconnect() {
+	logger := logrus.WithField("target", rc.target)
+
+	conn, err := grpc.NewClient(rc.target, rc.opts...)
+	if err != nil {
+		logger.WithError(err).Error("Failed to recreate gRPC connection")
+		return
+	}
+	// Connect right away so the next check sees the new connection's real state
+	conn.Connect()
+
+	rc.mu.Lock()
+	old := rc.conn
+	rc.conn = conn
+	rc.mu.Unlock()
+
+	old.Close()
+	logger.Warn("Recreated gRPC connection after repeated transient failures")
+}
@@ -0,0 +1,85 @@
+package clients
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	OrderCart "github.com/liju-github/CentralisedFoodbuddyMicroserviceProto/OrderCart"
+	Restaurant "github.com/liju-github/CentralisedFoodbuddyMicroserviceProto/Restaurant"
+	User "github.com/liju-github/CentralisedFoodbuddyMicroserviceProto/User"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// retryableMethods lists the read-only backend calls, which are safe to repeat.
+// Writes such as PlaceOrderByRestID are never retried, as a call that timed out may
+// still have been applied and repeating it could duplicate an order. Logins are not
+// retried either, so a failing backend does not multiply login attempts.
+var retryableMethods = map[string]bool{
+	User.UserService_GetProfile_FullMethodName:          true,
+	User.UserService_GetAddresses_FullMethodName:        true,
+	User.UserService_GetAllUsers_FullMethodName:         true,
+	User.UserService_GetUserByToken_FullMethodName:      true,
+	User.UserService_CheckBan_FullMethodName:            true,
+	User.UserService_ValidateUserAddress_FullMethodName: true,
+
+	Restaurant.RestaurantService_GetAllRestaurantWithProducts_FullMethodName: true,
+	Restaurant.RestaurantService_GetAllProducts_FullMethodName:               true,
+	Restaurant.RestaurantService_GetProductByID_FullMethodName:               true,
+	Restaurant.RestaurantService_GetRestaurantByID_FullMethodName:            true,
+	Restaurant.RestaurantService_GetRestaurantIDviaProductID_FullMethodName:  true,
+	Restaurant.RestaurantService_GetRestaurantProductsByID_FullMethodName:    true,
+	Restaurant.RestaurantService_GetStockByProductID_FullMethodName:          true,
+	Restaurant.RestaurantService_CheckRestaurantBanStatus_FullMethodName:     true,
+
+	OrderCart.OrderCartService_GetCartItems_FullMethodName:        true,
+	OrderCart.OrderCartService_GetAllCarts_FullMethodName:         true,
+	OrderCart.OrderCartService_GetCartByRestaurant_FullMethodName: true,
+	OrderCart.OrderCartService_GetOrderDetailsAll_FullMethodName:  true,
+	OrderCart.OrderCartService_GetOrderDetailsByID_FullMethodName: true,
+	OrderCart.OrderCartService_GetRestaurantOrders_FullMethodName: true,
+	OrderCart.OrderCartService_ValidateCartItems_FullMethodName:   true,
+}
+
+// RetryInterceptor retries read-only calls that failed with Unavailable or
+// DeadlineExceeded, up to maxAttempts calls in total, waiting backoff before the
+// second call and doubling it, with jitter, before each further one. Retries stop
+// once the caller's context is done. maxAttempts of 1 or less disables retries.
+func RetryInterceptor(maxAttempts int, backoff time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if maxAttempts <= 1 || !retryableMethods[method] {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		wait := backoff
+		for attempt := 1; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || attempt >= maxAttempts || !retryable(err) || ctx.Err() != nil {
+				return err
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"method":  method,
+				"attempt": attempt,
+			}).WithError(err).Warn("Retrying transient gRPC failure")
+
+			// Up to half the wait is random, so callers that failed together spread out
+			delay := wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(delay):
+			}
+			wait *= 2
+		}
+	}
+}
+
+// retryable reports whether err is a transient failure worth another attempt
+func retryable(err error) bool {
+	code := status.Code(err)
+	return code == codes.Unavailable || code == codes.DeadlineExceeded
+}
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
 	"github.com/liju-github/FoodBuddyAPIGateway/clients"
 	"github.com/liju-github/FoodBuddyAPIGateway/configs"
+	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
 	router "github.com/liju-github/FoodBuddyAPIGateway/route"
 )
 
@@ -23,12 +30,42 @@ func main() {
 	// Create a new Gin router
 	ginRouter := gin.Default()
 
+	// Turn away new requests as soon as shutdown begins
+	shutdown := middleware.NewShutdownState()
+	ginRouter.Use(middleware.ShutdownMiddleware(shutdown))
+
 	// Setup all routes
-	router.InitializeServiceRoutes(ginRouter, Client)
+	if err := router.InitializeServiceRoutes(ginRouter, Client); err != nil {
+		log.Fatalf("Failed to initialize routes: %v", err)
+	}
 
 	// Start the HTTP server (API Gateway)
-	log.Printf("API Gateway is running on port %s", config.APIGATEWAYPORT)
-	if err := ginRouter.Run(":" + config.APIGATEWAYPORT); err != nil {
-		log.Fatalf("Failed to start HTTP server: %v", err)
+	server := &http.Server{
+		Addr:    ":" + config.APIGATEWAYPORT,
+		Handler: ginRouter,
+	}
+	go func() {
+		log.Printf("API Gateway is running on port %s", config.APIGATEWAYPORT)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			if errors.Is(err, syscall.EADDRINUSE) {
+				log.Fatalf("Failed to start HTTP server: port %s is already in use. Another gateway instance or service is probably listening on it; stop it or set APIGATEWAYPORT to a free port", config.APIGATEWAYPORT)
+			}
+			log.Fatalf("Failed to start HTTP server: %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	// Stop accepting requests and give in-flight ones until the timeout to finish
+	shutdown.Begin()
+	log.Printf("Shutting down, draining %d in-flight requests for up to %s", shutdown.InFlight(), config.ShutdownTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Shutdown timed out with %d requests in flight, closing their connections: %v", shutdown.InFlight(), err)
+		server.Close()
 	}
+	log.Println("API Gateway stopped")
 }
@@ -2,7 +2,12 @@ package config
 
 import (
 	"log"
+	"net/url"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +20,212 @@ type Config struct {
 	RestaurantGRPCPort string
 	OrderCartGRPCPort  string
 	AdminGRPCPort      string
+	MinClientVersion   string
+
+	// Backend hosts, so services can run on other machines or containers
+	UserGRPCHost       string
+	RestaurantGRPCHost string
+	OrderCartGRPCHost  string
+	AdminGRPCHost      string
+
+	// JWTKeys maps key IDs to signing secrets, e.g. 2024b=secret, for rotating the
+	// secret. Tokens are signed with JWTSigningKeyID, tokens naming a key no longer
+	// listed are rejected, and JWTSecretKey only verifies tokens issued without a key
+	// ID. Without JWTKeys, JWTSecretKey signs everything as before.
+	JWTKeys         map[string]string
+	JWTSigningKeyID string
+
+	// GRPCUseTLS dials every backend over TLS, verified against GRPCCACertPath or,
+	// when that is empty, the system certificate pool
+	GRPCUseTLS     bool
+	GRPCCACertPath string
+
+	// ClientPlatformMinVersions overrides MinClientVersion per X-Client-Platform, e.g. ios=2.3.0
+	ClientPlatformMinVersions map[string]string
+
+	// Browsers may authenticate with an HttpOnly cookie instead of the Authorization header
+	AuthCookieEnabled bool
+	AuthCookieName    string
+	// CORSAllowedOrigins lists the browser origins allowed to call the gateway with
+	// credentials, which cookie auth needs. When empty any origin may call it, but
+	// without credentials.
+	CORSAllowedOrigins []string
+
+	RequireVerificationBeforeLogin bool
+	AdminReplayProtection          bool
+	ImpersonationTokenTTL          time.Duration
+
+	// Login issues a short-lived access token and a refresh token that can be
+	// exchanged for new ones until MaxSessionLifetime after the login
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// MaxSessionLifetime bounds how long a login can be kept alive by refreshing
+	// before a full re-login is required
+	MaxSessionLifetime time.Duration
+
+	// Tenants lists the brands served by this gateway. Empty disables tenant resolution.
+	Tenants []string
+
+	// ServiceablePincodePrefixes limits user addresses to these pincode prefixes. Empty allows all.
+	ServiceablePincodePrefixes []string
+
+	// BlockedEmailDomains and the domains listed in BlockedEmailDomainsFile may not sign up. Empty allows all.
+	BlockedEmailDomains     []string
+	BlockedEmailDomainsFile string
+
+	PasswordMinLength        int
+	PasswordMaxLength        int
+	PasswordRequireUppercase bool
+	PasswordRequireDigit     bool
+	PasswordRequireSpecial   bool
+
+	VerificationCodeLength int
+	LowStockThreshold      int
+	MaxOrderItems          int
+	MaxOrderTotalQuantity  int
+
+	// MaxProductPrice is the highest price a product may be listed at. Zero disables the bound.
+	MaxProductPrice float64
+
+	// RequireSameStateDelivery rejects orders delivered to a different state than the restaurant's
+	RequireSameStateDelivery bool
+
+	DefaultPrepTime     time.Duration
+	RestaurantPrepTimes map[string]time.Duration
+
+	// Delivery fees are a base fee plus a fee per estimated km. A zero per-km fee makes
+	// the fee flat, and a zero max distance delivers any distance.
+	DeliveryBaseFee       float64
+	DeliveryFeePerKm      float64
+	MaxDeliveryDistanceKm float64
+
+	// Operating hours are windows such as 09:00-22:00 in OperatingHoursTimezone.
+	// An empty DefaultOperatingHours means restaurants without their own hours are always open.
+	DefaultOperatingHours    string
+	RestaurantOperatingHours map[string]string
+	OperatingHoursTimezone   string
+
+	MaintenanceMode       bool
+	MaintenanceRetryAfter time.Duration
+
+	// RequestTimeout bounds a whole request, across all of its backend calls. Zero disables it.
+	RequestTimeout time.Duration
+
+	// ShutdownTimeout is how long in-flight requests may take to finish on shutdown
+	// before their connections are closed
+	ShutdownTimeout time.Duration
+
+	BackendTimeout    time.Duration
+	UserTimeout       time.Duration
+	RestaurantTimeout time.Duration
+	OrderCartTimeout  time.Duration
+	AdminTimeout      time.Duration
+
+	MaxBackendConcurrency int
+
+	// RateLimitWarnRatio is the share of a rate limit after which responses carry
+	// X-RateLimit-Warning, so clients can slow down before being throttled. Zero
+	// disables the warning.
+	RateLimitWarnRatio float64
+
+	// RateLimitRequests is the number of requests an IP may make per RateLimitWindow.
+	// The in-memory limiter forgets IPs inactive for RateLimitTTL.
+	RateLimitRequests int
+	RateLimitWindow   time.Duration
+	RateLimitTTL      time.Duration
+
+	// RateLimitGlobal applies the per-IP rate limit to every route instead of only
+	// the login and signup routes. Admin routes then get RateLimitAdminRequests per
+	// window instead, counted once the caller is authenticated as an admin.
+	RateLimitGlobal        bool
+	RateLimitAdminRequests int
+
+	// DataExportLimit is the number of successful data exports a user may make per
+	// DataExportWindow
+	DataExportLimit  int
+	DataExportWindow time.Duration
+
+	// RateLimitBackend keeps the per-IP rate limit counters in "memory", per gateway
+	// instance, or in "redis" at RedisURL, shared by all replicas
+	RateLimitBackend string
+	RedisURL         string
+	RedisTimeout     time.Duration
+
+	// FavoritesBackend stores user favorites in "redis" at RedisURL. Left empty, the
+	// favorites routes answer 501, as no backend service stores favorites yet.
+	FavoritesBackend string
+
+	// TokenBlacklistBackend keeps revoked and consumed token IDs in "memory", per
+	// gateway instance, or in "redis" at RedisURL, shared by all replicas
+	TokenBlacklistBackend string
+
+	// TimestampFields are the response fields rewritten to RFC3339 UTC, at any depth
+	TimestampFields []string
+
+	// ResponseDenyFields are internal fields stripped from every JSON response, either
+	// a bare name or parent.name. ResponseAllowFields lets a route template keep some,
+	// e.g. /admin/users/list=isBanned|banReason.
+	ResponseDenyFields  []string
+	ResponseAllowFields map[string][]string
+
+	// Backend connections stuck in transient failure for GRPCReconnectFailures
+	// consecutive checks, GRPCReconnectInterval apart, are recreated so the target is
+	// resolved again. A zero interval disables the checks.
+	GRPCReconnectInterval time.Duration
+	GRPCReconnectFailures int
+
+	// Read-only backend calls failing with Unavailable or DeadlineExceeded are made
+	// up to GRPCRetryMaxAttempts times, waiting GRPCRetryBackoff before the first
+	// retry and doubling it after each. 1 disables retries.
+	GRPCRetryMaxAttempts int
+	GRPCRetryBackoff     time.Duration
+
+	// A backend's circuit breaker opens after GRPCBreakerFailures consecutive
+	// failures, failing its calls at once for GRPCBreakerCooldown before one trial
+	// call is let through. Zero disables the breakers.
+	GRPCBreakerFailures int
+	GRPCBreakerCooldown time.Duration
+
+	// Requests slower than their route's SLA are logged and counted. Zero disables the default.
+	DefaultRouteSLA time.Duration
+	RouteSLAs       map[string]time.Duration
+
+	PublicListingCacheTTL  time.Duration
+	RestaurantNameCacheTTL time.Duration
+	InventoryValueCacheTTL time.Duration
+
+	TrendingWindow   time.Duration
+	TrendingCacheTTL time.Duration
+	TrendingLimit    int
+
+	RestaurantMetricsWindow   time.Duration
+	RestaurantMetricsCacheTTL time.Duration
+
+	// OrderCountsCacheTTL is how long a restaurant's order counts per status are
+	// cached. Keep it short, the counts drive dashboard badges.
+	OrderCountsCacheTTL time.Duration
+
+	// OrderCodeTTL is how long the short code assigned to a placed order can be looked up
+	OrderCodeTTL time.Duration
+
+	// HealthCheckTimeout bounds each backend health check made by /health and /ready
+	HealthCheckTimeout time.Duration
+	// ReadyRequiredServices lists the backends that must be healthy for /ready to
+	// succeed, out of user, restaurant, orderCart and admin
+	ReadyRequiredServices []string
+
+	// PlatformCommissionPercent is deducted from a restaurant's delivered order revenue
+	PlatformCommissionPercent float64
+	MaxEarningsRangeDays      int
+
+	// RestaurantWebhooks maps restaurant IDs to webhook URLs. Each restaurant's events
+	// are signed with its own secret in RestaurantWebhookSecrets, so one restaurant
+	// cannot forge events to another.
+	RestaurantWebhooks       map[string]string
+	RestaurantWebhookSecrets map[string]string
+	WebhookTimeout           time.Duration
+	WebhookMaxRetries        int
 }
 
 func LoadConfig() Config {
@@ -22,6 +233,9 @@ func LoadConfig() Config {
 		log.Println("No .env file found, using system environment variables")
 	}
 
+	// Per-service timeouts fall back to the global backend timeout when unset
+	backendTimeout := getDurationEnv("BACKENDTIMEOUT", 10*time.Second)
+
 	return Config{
 		APIGATEWAYPORT:     os.Getenv("APIGATEWAYPORT"),
 		JWTSecretKey:       os.Getenv("JWTSECRET"),
@@ -30,5 +244,345 @@ func LoadConfig() Config {
 		OrderCartGRPCPort:  os.Getenv("ORDERCARTGRPCPORT"),
 		AdminGRPCPort:      os.Getenv("ADMINGRPCPORT"),
 		Environment:        os.Getenv("ENVIRONMENT"),
+		MinClientVersion:   os.Getenv("MINCLIENTVERSION"),
+
+		UserGRPCHost:       getEnv("USERGRPCHOST", "localhost"),
+		RestaurantGRPCHost: getEnv("RESTAURANTGRPCHOST", "localhost"),
+		OrderCartGRPCHost:  getEnv("ORDERCARTGRPCHOST", "localhost"),
+		AdminGRPCHost:      getEnv("ADMINGRPCHOST", "localhost"),
+
+		JWTKeys:         getMapEnv("JWTKEYS"),
+		JWTSigningKeyID: os.Getenv("JWTSIGNINGKEYID"),
+
+		GRPCUseTLS:     getBoolEnv("GRPCUSETLS", false),
+		GRPCCACertPath: os.Getenv("GRPCCACERTPATH"),
+
+		ClientPlatformMinVersions: getMapEnv("CLIENTPLATFORMMINVERSIONS"),
+
+		AuthCookieEnabled:  getBoolEnv("AUTHCOOKIEENABLED", false),
+		AuthCookieName:     getEnv("AUTHCOOKIENAME", "foodbuddy_token"),
+		CORSAllowedOrigins: getListEnv("CORSALLOWEDORIGINS"),
+
+		RequireVerificationBeforeLogin: getBoolEnv("REQUIREVERIFICATIONBEFORELOGIN", false),
+		AdminReplayProtection:          getBoolEnv("ADMINREPLAYPROTECTION", false),
+		ImpersonationTokenTTL:          getDurationEnv("IMPERSONATIONTOKENTTL", 15*time.Minute),
+
+		AccessTokenTTL:     getDurationEnv("ACCESSTOKENTTL", 15*time.Minute),
+		RefreshTokenTTL:    getDurationEnv("REFRESHTOKENTTL", 7*24*time.Hour),
+		MaxSessionLifetime: getDurationEnv("MAXSESSIONLIFETIME", 30*24*time.Hour),
+
+		Tenants: getListEnv("TENANTS"),
+
+		ServiceablePincodePrefixes: getListEnv("SERVICEABLEPINCODEPREFIXES"),
+
+		BlockedEmailDomains:     getListEnv("BLOCKEDEMAILDOMAINS"),
+		BlockedEmailDomainsFile: os.Getenv("BLOCKEDEMAILDOMAINSFILE"),
+
+		PasswordMinLength:        getIntEnv("PASSWORDMINLENGTH", 8),
+		PasswordMaxLength:        getIntEnv("PASSWORDMAXLENGTH", 72),
+		PasswordRequireUppercase: getBoolEnv("PASSWORDREQUIREUPPERCASE", false),
+		PasswordRequireDigit:     getBoolEnv("PASSWORDREQUIREDIGIT", false),
+		PasswordRequireSpecial:   getBoolEnv("PASSWORDREQUIRESPECIAL", false),
+
+		VerificationCodeLength: getIntEnv("VERIFICATIONCODELENGTH", 6),
+		LowStockThreshold:      getIntEnv("LOWSTOCKTHRESHOLD", 5),
+		MaxOrderItems:          getIntEnv("MAXORDERITEMS", 50),
+		MaxOrderTotalQuantity:  getIntEnv("MAXORDERTOTALQUANTITY", 100),
+
+		MaxProductPrice: getFloatEnv("MAXPRODUCTPRICE", 100000),
+
+		RequireSameStateDelivery: getBoolEnv("REQUIRESAMESTATEDELIVERY", false),
+
+		DefaultPrepTime:     getDurationEnv("DEFAULTPREPTIME", 20*time.Minute),
+		RestaurantPrepTimes: getDurationMapEnv("RESTAURANTPREPTIMES"),
+
+		DeliveryBaseFee:       getFloatEnv("DELIVERYBASEFEE", 20),
+		DeliveryFeePerKm:      getFloatEnv("DELIVERYFEEPERKM", 5),
+		MaxDeliveryDistanceKm: getFloatEnv("MAXDELIVERYDISTANCEKM", 25),
+
+		DefaultOperatingHours:    os.Getenv("DEFAULTOPERATINGHOURS"),
+		RestaurantOperatingHours: getMapEnv("RESTAURANTOPERATINGHOURS"),
+		OperatingHoursTimezone:   getEnv("OPERATINGHOURSTIMEZONE", "Asia/Kolkata"),
+
+		MaintenanceMode:       getBoolEnv("MAINTENANCEMODE", false),
+		MaintenanceRetryAfter: getDurationEnv("MAINTENANCERETRYAFTER", 5*time.Minute),
+
+		RequestTimeout: getDurationEnv("REQUESTTIMEOUT", 30*time.Second),
+
+		ShutdownTimeout: getDurationEnv("SHUTDOWNTIMEOUT", 30*time.Second),
+
+		BackendTimeout:    backendTimeout,
+		UserTimeout:       getDurationEnv("USERTIMEOUT", backendTimeout),
+		RestaurantTimeout: getDurationEnv("RESTAURANTTIMEOUT", backendTimeout),
+		OrderCartTimeout:  getDurationEnv("ORDERCARTTIMEOUT", backendTimeout),
+		AdminTimeout:      getDurationEnv("ADMINTIMEOUT", backendTimeout),
+
+		MaxBackendConcurrency: getIntEnv("MAXBACKENDCONCURRENCY", 10),
+
+		RateLimitWarnRatio: getFloatEnv("RATELIMITWARNRATIO", 0.8),
+
+		RateLimitRequests: getIntEnv("RATELIMITREQUESTS", 60),
+		RateLimitWindow:   getDurationEnv("RATELIMITWINDOW", time.Minute),
+		RateLimitTTL:      getDurationEnv("RATELIMITTTL", 3*time.Minute),
+		RateLimitGlobal:   getBoolEnv("RATELIMITGLOBAL", false),
+
+		RateLimitAdminRequests: getIntEnv("RATELIMITADMINREQUESTS", 600),
+
+		DataExportLimit:  getIntEnv("DATAEXPORTLIMIT", 1),
+		DataExportWindow: getDurationEnv("DATAEXPORTWINDOW", 24*time.Hour),
+
+		RateLimitBackend: getEnv("RATELIMITBACKEND", "memory"),
+		RedisURL:         getEnv("REDISURL", "redis://localhost:6379/0"),
+		RedisTimeout:     getDurationEnv("REDISTIMEOUT", 500*time.Millisecond),
+
+		FavoritesBackend: getEnv("FAVORITESBACKEND", ""),
+
+		TokenBlacklistBackend: getEnv("TOKENBLACKLISTBACKEND", "memory"),
+
+		TimestampFields: getListEnvDefault("TIMESTAMPFIELDS", []string{"createdAt", "updatedAt", "deletedAt", "issuedAt", "expiresAt"}),
+
+		ResponseDenyFields:  getListEnvDefault("RESPONSEDENYFIELDS", []string{"password", "passwordHash", "verificationCode", "deletedAt", "isDeleted"}),
+		ResponseAllowFields: getListMapEnv("RESPONSEALLOWFIELDS"),
+
+		GRPCReconnectInterval: getDurationEnv("GRPCRECONNECTINTERVAL", 30*time.Second),
+		GRPCReconnectFailures: getIntEnv("GRPCRECONNECTFAILURES", 3),
+
+		GRPCRetryMaxAttempts: getIntEnv("GRPCRETRYMAXATTEMPTS", 3),
+		GRPCRetryBackoff:     getDurationEnv("GRPCRETRYBACKOFF", 100*time.Millisecond),
+
+		GRPCBreakerFailures: getIntEnv("GRPCBREAKERFAILURES", 5),
+		GRPCBreakerCooldown: getDurationEnv("GRPCBREAKERCOOLDOWN", 30*time.Second),
+
+		DefaultRouteSLA: getDurationEnv("DEFAULTROUTESLA", 0),
+		RouteSLAs:       getDurationMapEnv("ROUTESLAS"),
+
+		PublicListingCacheTTL:  getDurationEnv("PUBLICLISTINGCACHETTL", 30*time.Second),
+		RestaurantNameCacheTTL: getDurationEnv("RESTAURANTNAMECACHETTL", 10*time.Minute),
+		InventoryValueCacheTTL: getDurationEnv("INVENTORYVALUECACHETTL", time.Minute),
+
+		TrendingWindow:   getDurationEnv("TRENDINGWINDOW", 7*24*time.Hour),
+		TrendingCacheTTL: getDurationEnv("TRENDINGCACHETTL", 5*time.Minute),
+		TrendingLimit:    getIntEnv("TRENDINGLIMIT", 20),
+
+		RestaurantMetricsWindow:   getDurationEnv("RESTAURANTMETRICSWINDOW", 30*24*time.Hour),
+		RestaurantMetricsCacheTTL: getDurationEnv("RESTAURANTMETRICSCACHETTL", 5*time.Minute),
+
+		OrderCountsCacheTTL: getDurationEnv("ORDERCOUNTSCACHETTL", 15*time.Second),
+
+		OrderCodeTTL: getDurationEnv("ORDERCODETTL", 30*24*time.Hour),
+
+		HealthCheckTimeout:    getDurationEnv("HEALTHCHECKTIMEOUT", 2*time.Second),
+		ReadyRequiredServices: getListEnvDefault("READYREQUIREDSERVICES", []string{"user", "restaurant", "orderCart", "admin"}),
+
+		PlatformCommissionPercent: getFloatEnv("PLATFORMCOMMISSIONPERCENT", 0),
+		MaxEarningsRangeDays:      getIntEnv("MAXEARNINGSRANGEDAYS", 366),
+
+		RestaurantWebhooks:       getMapEnv("RESTAURANTWEBHOOKS"),
+		RestaurantWebhookSecrets: getMapEnv("RESTAURANTWEBHOOKSECRETS"),
+		WebhookTimeout:           getDurationEnv("WEBHOOKTIMEOUT", 5*time.Second),
+		WebhookMaxRetries:        getIntEnv("WEBHOOKMAXRETRIES", 3),
+	}
+}
+
+// getEnv returns the environment value for key, falling back to def when unset
+func getEnv(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// getDurationEnv parses a duration such as "30s" from the environment, falling back to def
+func getDurationEnv(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid duration %q for %s, using default %s", value, key, def)
+		return def
+	}
+	return duration
+}
+
+// getIntEnv parses an integer from the environment, falling back to def
+func getIntEnv(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid integer %q for %s, using default %d", value, key, def)
+		return def
+	}
+	return number
+}
+
+// getFloatEnv parses a decimal number from the environment, falling back to def
+func getFloatEnv(key string, def float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid number %q for %s, using default %g", value, key, def)
+		return def
+	}
+	return number
+}
+
+// getMapEnv parses a comma separated list of key=value pairs from the environment
+func getMapEnv(key string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || k == "" || v == "" {
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// getListEnv parses a comma separated list from the environment, skipping empty entries
+func getListEnv(key string) []string {
+	var result []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// getListEnvDefault is getListEnv falling back to def when the variable is unset.
+// Setting it to an empty value yields an empty list.
+func getListEnvDefault(key string, def []string) []string {
+	if _, ok := os.LookupEnv(key); !ok {
+		return def
+	}
+	return getListEnv(key)
+}
+
+// getListMapEnv parses a comma separated list of key=value pairs from the environment,
+// where each value is a | separated list
+func getListMapEnv(key string) map[string][]string {
+	result := make(map[string][]string)
+	for k, v := range getMapEnv(key) {
+		for _, item := range strings.Split(v, "|") {
+			if item = strings.TrimSpace(item); item != "" {
+				result[k] = append(result[k], item)
+			}
+		}
+	}
+	return result
+}
+
+// getBoolEnv parses a boolean such as "true" from the environment, falling back to def
+func getBoolEnv(key string, def bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid boolean %q for %s, using default %t", value, key, def)
+		return def
+	}
+	return b
+}
+
+// getDurationMapEnv parses a comma separated list of key=duration pairs from the environment
+func getDurationMapEnv(key string) map[string]time.Duration {
+	result := make(map[string]time.Duration)
+	for k, v := range getMapEnv(key) {
+		duration, err := time.ParseDuration(v)
+		if err != nil {
+			log.Printf("Invalid duration %q for %s in %s, ignoring", v, k, key)
+			continue
+		}
+		result[k] = duration
+	}
+	return result
+}
+
+// secretFields lists the Config fields that must never be exposed in plain text
+var secretFields = map[string]bool{
+	"JWTSecretKey":             true,
+	"JWTKeys":                  true,
+	"RedisURL":                 true,
+	"RestaurantWebhookSecrets": true,
+}
+
+// urlFields lists the Config fields holding URLs that may carry credentials in
+// their user info, path or query
+var urlFields = map[string]bool{
+	"RestaurantWebhooks": true,
+}
+
+// Redacted returns the config as a field name to value map for diagnostics.
+// Secrets are reported only as set or unset, URLs that may embed tokens are cut
+// down to their scheme and host, and durations are rendered in human readable form.
+func (c Config) Redacted() map[string]interface{} {
+	result := make(map[string]interface{})
+	value := reflect.ValueOf(c)
+	for i := 0; i < value.NumField(); i++ {
+		name := value.Type().Field(i).Name
+		field := value.Field(i).Interface()
+
+		switch v := field.(type) {
+		case string:
+			if secretFields[name] {
+				field = secretState(v)
+			}
+		case map[string]string:
+			if secretFields[name] || urlFields[name] {
+				redacted := make(map[string]string, len(v))
+				for key, value := range v {
+					if secretFields[name] {
+						redacted[key] = secretState(value)
+					} else {
+						redacted[key] = redactURL(value)
+					}
+				}
+				field = redacted
+			}
+		case time.Duration:
+			field = v.String()
+		case map[string]time.Duration:
+			durations := make(map[string]string, len(v))
+			for key, duration := range v {
+				durations[key] = duration.String()
+			}
+			field = durations
+		}
+		result[name] = field
+	}
+	return result
+}
+
+// secretState reports whether a secret is configured without revealing anything
+// about its value
+func secretState(secret string) string {
+	if secret == "" {
+		return "(unset)"
+	}
+	return "(set)"
+}
+
+// redactURL keeps only the scheme and host of rawURL, dropping any credentials,
+// path and query
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return "(redacted)"
 	}
+	return parsed.Scheme + "://" + parsed.Host + "/(redacted)"
 }
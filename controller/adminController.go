@@ -1,54 +1,142 @@
 package controller
 
 import (
-	"context"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	adminPb "github.com/liju-github/CentralisedFoodbuddyMicroserviceProto/Admin"
 	config "github.com/liju-github/FoodBuddyAPIGateway/configs"
 	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
+	"github.com/liju-github/FoodBuddyAPIGateway/model"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type AdminController struct {
 	adminClient adminPb.AdminServiceClient
-	jwtSecret   []byte
+	sessions    sessionTokens
+	timeout     time.Duration
+	maintenance *middleware.MaintenanceMode
+	logger      *logrus.Logger
+	cookies     authCookies
+
+	// config is the configuration the gateway started with, reported by GetConfig
+	config config.Config
 }
 
-func NewAdminController(adminClient adminPb.AdminServiceClient) *AdminController {
+func NewAdminController(cfg config.Config, adminClient adminPb.AdminServiceClient, tokens *middleware.TokenService, maintenance *middleware.MaintenanceMode) *AdminController {
+	logger := logrus.New()
 	return &AdminController{
 		adminClient: adminClient,
-		jwtSecret:   []byte(config.LoadConfig().JWTSecretKey),
+		maintenance: maintenance,
+		logger:      logger,
+		sessions:    newSessionTokens(middleware.RoleAdmin, tokens, cfg, logger),
+		timeout:     cfg.AdminTimeout,
+		cookies:     newAuthCookies(cfg),
+		config:      cfg,
 	}
 }
 
 func (ac *AdminController) AdminLogin(ctx *gin.Context) {
-	var AdminLoginRequest adminPb.AdminLoginRequest
-	if err := ctx.ShouldBindJSON(&AdminLoginRequest); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	request, ok := bindJSON[model.AdminLoginRequest](ctx, ac.logger)
+	if !ok {
 		return
 	}
 
-	response, err := ac.adminClient.AdminLogin(context.Background(), &AdminLoginRequest)
+	grpcCtx, cancel := middleware.WithRequestTimeout(ctx, ac.timeout)
+	defer cancel()
+
+	logger := middleware.RequestLogger(ctx, ac.logger)
+
+	start := time.Now()
+	response, err := ac.adminClient.AdminLogin(grpcCtx, &adminPb.AdminLoginRequest{
+		Username: request.Username,
+		Password: request.Password,
+	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if abortIfUnanswered(ctx, err) {
+			return
+		}
+		code := status.Code(err)
+		logger.WithFields(logrus.Fields{
+			"username": request.Username,
+			"grpcCode": code.String(),
+			"elapsed":  time.Since(start).String(),
+			"timeout":  ac.timeout.String(),
+			"error":    err.Error(),
+		}).Error("Admin login failed")
+
+		// A slow or unreachable backend is reported separately from rejected credentials
+		if code == codes.DeadlineExceeded || code == codes.Unavailable {
+			ctx.JSON(http.StatusServiceUnavailable, model.ErrorResponseWithCode(model.CodeAdminServiceUnavailable, model.ErrAdminServiceUnavailable, err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse(model.ErrLoginFailed, err))
+		return
+	}
+
+	if !response.Success {
+		logger.WithField("username", request.Username).Warn("Admin login rejected")
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse(model.ErrLoginFailed, nil))
 		return
 	}
 
-	response.Token, _ = ac.generateToken("admin")
+	// The Admin service does not return an admin ID, so the username it just
+	// authenticated identifies the admin in the token and in audit logs
+	adminID := request.Username
+
+	// A fresh login starts a new session
+	tokens, err := ac.sessions.issue(adminID, time.Now().Unix(), logger)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse(model.ErrFailedGenerateToken, err))
+		return
+	}
+	response.Token = tokens.Token
+	ac.cookies.set(ctx, response.Token)
 
-	ctx.JSON(http.StatusOK, response)
+	ctx.JSON(http.StatusOK, struct {
+		*adminPb.AdminLoginResponse
+		RefreshToken string `json:"refreshToken"`
+		ExpiresIn    int64  `json:"expiresIn"`
+	}{response, tokens.RefreshToken, tokens.ExpiresIn})
 }
 
-func (ac *AdminController) generateToken(ID string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":      ID,
-		"role":    middleware.RoleAdmin,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
-		"created": time.Now().Unix(),
-	})
+// RefreshToken exchanges an admin refresh token for a new access and refresh token
+func (ac *AdminController) RefreshToken(ctx *gin.Context) {
+	ac.sessions.refresh(ctx)
+}
+
+// GetMaintenanceMode reports whether maintenance mode is on
+func (ac *AdminController) GetMaintenanceMode(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, model.SuccessResponse(model.MsgMaintenanceStatus, gin.H{
+		"enabled": ac.maintenance.Enabled(),
+	}))
+}
+
+// SetMaintenanceMode turns maintenance mode on or off
+func (ac *AdminController) SetMaintenanceMode(ctx *gin.Context) {
+	request, ok := bindJSON[model.MaintenanceModeRequest](ctx, ac.logger)
+	if !ok {
+		return
+	}
+
+	ac.maintenance.SetEnabled(*request.Enabled)
+
+	adminID, _ := middleware.GetEntityID(ctx)
+	middleware.RequestLogger(ctx, ac.logger).WithFields(logrus.Fields{
+		"audit":   true,
+		"adminId": adminID,
+		"enabled": *request.Enabled,
+	}).Warn("Maintenance mode changed")
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse(model.MsgMaintenanceUpdated, gin.H{
+		"enabled": *request.Enabled,
+	}))
+}
 
-	return token.SignedString(ac.jwtSecret)
+// GetConfig returns the configuration the gateway started with, with secrets redacted
+func (ac *AdminController) GetConfig(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, model.SuccessResponse(model.MsgConfigRetrieved, ac.config.Redacted()))
 }
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	config "github.com/liju-github/FoodBuddyAPIGateway/configs"
+	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
+	"github.com/liju-github/FoodBuddyAPIGateway/model"
+	"github.com/sirupsen/logrus"
+)
+
+// AuthController handles session endpoints shared by every role
+type AuthController struct {
+	tokens  *middleware.TokenService
+	logger  *logrus.Logger
+	cookies authCookies
+}
+
+func NewAuthController(cfg config.Config, tokens *middleware.TokenService) *AuthController {
+	return &AuthController{
+		tokens:  tokens,
+		logger:  logrus.New(),
+		cookies: newAuthCookies(cfg),
+	}
+}
+
+// Logout revokes the caller's access token and, when one is sent in the body, the
+// refresh token of the session, so neither can be used again. Must run after
+// JWTAuthMiddleware.
+func (ac *AuthController) Logout(c *gin.Context) {
+	logger := middleware.RequestLogger(c, ac.logger)
+	id, _ := middleware.GetEntityID(c)
+	role, _ := middleware.GetEntityRole(c)
+
+	var request model.LogoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrInvalidRequestFormat, err))
+			return
+		}
+	}
+
+	if request.RefreshToken != "" {
+		claims, err := ac.tokens.ParseRefresh(request.RefreshToken)
+		if err != nil || claims.ID != id || claims.Role != role {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrInvalidRefreshToken, nil))
+			return
+		}
+		middleware.RevokeToken(claims.RegisteredClaims.ID, claims.ExpiresAt.Time)
+	}
+
+	tokenID := c.GetString(middleware.TokenIDKey)
+	if expiresAt, ok := c.Get(middleware.TokenExpiryKey); ok {
+		middleware.RevokeToken(tokenID, expiresAt.(time.Time))
+	}
+	if tokenID == "" {
+		// Tokens issued before revocation existed stay valid until they expire
+		logger.WithField("id", id).Warn("Logged out token has no jti and cannot be revoked")
+	}
+	ac.cookies.clear(c)
+
+	logger.WithFields(logrus.Fields{
+		"id":   id,
+		"role": role,
+	}).Info("Logged out")
+
+	c.JSON(http.StatusOK, model.SuccessResponse(model.MsgLoggedOut, nil))
+}
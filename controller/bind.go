@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
+	"github.com/liju-github/FoodBuddyAPIGateway/model"
+	"github.com/sirupsen/logrus"
+)
+
+// bindJSON binds the request body into a new T. On failure it logs the error,
+// writes the standard 400 response and returns ok=false, so handlers can simply return.
+func bindJSON[T any](c *gin.Context, logger *logrus.Logger) (*T, bool) {
+	request := new(T)
+	if err := c.ShouldBindJSON(request); err != nil {
+		middleware.RequestLogger(c, logger).WithFields(logrus.Fields{
+			"error": err.Error(),
+			"path":  c.FullPath(),
+		}).Error("Failed to bind request")
+		c.JSON(http.StatusBadRequest, model.BindErrorResponse(err))
+		return nil, false
+	}
+	return request, true
+}
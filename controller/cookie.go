@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	config "github.com/liju-github/FoodBuddyAPIGateway/configs"
+	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
+)
+
+// authCookies sets the HttpOnly cookie browsers authenticate with, plus a readable
+// CSRF cookie whose value they must echo in the X-CSRF-Token header on requests
+// that change state. Nothing is set unless cookie auth is enabled.
+type authCookies struct {
+	enabled bool
+	name    string
+	// ttl matches the lifetime of the access tokens the cookie carries
+	ttl time.Duration
+}
+
+func newAuthCookies(cfg config.Config) authCookies {
+	return authCookies{
+		enabled: cfg.AuthCookieEnabled,
+		name:    cfg.AuthCookieName,
+		ttl:     cfg.AccessTokenTTL,
+	}
+}
+
+// set issues the cookies for token. Failing to generate a CSRF token only leaves
+// the client on header based auth, so it is not reported as an error.
+func (a authCookies) set(c *gin.Context, token string) {
+	if !a.enabled {
+		return
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return
+	}
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     a.name,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(a.ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     middleware.CSRFCookieName,
+		Value:    hex.EncodeToString(buf),
+		Path:     "/",
+		MaxAge:   int(a.ttl.Seconds()),
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// clear expires both cookies, so a logged out browser stops sending the token
+func (a authCookies) clear(c *gin.Context) {
+	if !a.enabled {
+		return
+	}
+
+	for _, name := range []string{a.name, middleware.CSRFCookieName} {
+		http.SetCookie(c.Writer, &http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     "/",
+			MaxAge:   -1,
+			HttpOnly: name == a.name,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liju-github/FoodBuddyAPIGateway/clients"
+	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StatusClientClosedRequest is the non-standard status (popularised by nginx) for
+// requests the client abandoned before a response was written
+const StatusClientClosedRequest = 499
+
+// abortIfUnanswered ends the request when err is a backend call that got no answer:
+// 499 when it was canceled because the client disconnected, and 503 when the
+// backend's circuit breaker refused it. Client cancellations are routine, so they
+// are logged at debug level instead of as errors.
+func abortIfUnanswered(c *gin.Context, err error) bool {
+	var open *clients.CircuitOpenError
+	if errors.As(err, &open) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(open.RetryAfter.Seconds()))))
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"message": "Service temporarily unavailable, please retry later",
+		})
+		c.Abort()
+		return true
+	}
+
+	if status.Code(err) != codes.Canceled || c.Request.Context().Err() == nil {
+		return false
+	}
+
+	middleware.RequestLogger(c, logrus.StandardLogger()).WithError(err).Debug("Client closed request")
+	c.AbortWithStatus(StatusClientClosedRequest)
+	return true
+}
@@ -0,0 +1,186 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	restaurantPb "github.com/liju-github/CentralisedFoodbuddyMicroserviceProto/Restaurant"
+	config "github.com/liju-github/FoodBuddyAPIGateway/configs"
+	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
+	"github.com/liju-github/FoodBuddyAPIGateway/model"
+	"github.com/liju-github/FoodBuddyAPIGateway/utils"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type FavoritesController struct {
+	restaurantClient restaurantPb.RestaurantServiceClient
+	store            utils.FavoritesStore
+	logger           *logrus.Logger
+	timeout          time.Duration
+	fanOutLimit      int
+}
+
+// NewFavoritesController creates the favorites handlers. With a nil store they answer
+// 501, as no backend service stores favorites yet.
+func NewFavoritesController(cfg config.Config, logger *logrus.Logger, restaurantClient restaurantPb.RestaurantServiceClient, store utils.FavoritesStore) *FavoritesController {
+	return &FavoritesController{
+		restaurantClient: restaurantClient,
+		store:            store,
+		logger:           logger,
+		timeout:          cfg.RestaurantTimeout,
+		fanOutLimit:      cfg.MaxBackendConcurrency,
+	}
+}
+
+// backendContext returns a context for a backend call made on behalf of c, bounded by
+// the Restaurant service timeout and cancelled if the client disconnects
+func (fc *FavoritesController) backendContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	return middleware.WithRequestTimeout(c, fc.timeout)
+}
+
+// abortIfUnsupported answers 501 when no favorites store is configured
+func (fc *FavoritesController) abortIfUnsupported(c *gin.Context) bool {
+	if fc.store != nil {
+		return false
+	}
+	c.JSON(http.StatusNotImplemented, model.ErrorResponse(model.ErrFavoritesNotSupported, nil))
+	return true
+}
+
+// AddFavorite marks a restaurant as a favorite of the authenticated user
+func (fc *FavoritesController) AddFavorite(c *gin.Context) {
+	if fc.abortIfUnsupported(c) {
+		return
+	}
+	logger := middleware.RequestLogger(c, fc.logger)
+	request, ok := bindJSON[model.AddFavoriteRequest](c, fc.logger)
+	if !ok {
+		return
+	}
+
+	userID, exists := middleware.GetEntityID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.ErrUserIDNotFound, nil))
+		return
+	}
+
+	restaurantID := strings.TrimSpace(request.RestaurantID)
+
+	ctx, cancel := fc.backendContext(c)
+	defer cancel()
+
+	restaurant, err := fc.restaurantClient.GetRestaurantByID(ctx, &restaurantPb.GetRestaurantByIDRequest{
+		RestaurantId: restaurantID,
+	})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		if status.Code(err) == codes.NotFound {
+			c.JSON(http.StatusNotFound, model.ErrorResponse(model.ErrRestaurantNotFound, err))
+			return
+		}
+		logger.WithError(err).WithField("restaurantId", restaurantID).Error("Failed to get restaurant for favorite")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.ErrFailedAddFavorite, err))
+		return
+	}
+	if restaurant.IsBanned {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrRestaurantUnavailable, nil))
+		return
+	}
+
+	if err := fc.store.Add(userID, restaurantID); err != nil {
+		logger.WithError(err).WithField("userId", userID).Error("Failed to add favorite")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.ErrFailedAddFavorite, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SuccessResponse(model.MsgFavoriteAdded, gin.H{"restaurantId": restaurantID}))
+}
+
+// RemoveFavorite unmarks a restaurant as a favorite of the authenticated user
+func (fc *FavoritesController) RemoveFavorite(c *gin.Context) {
+	if fc.abortIfUnsupported(c) {
+		return
+	}
+	logger := middleware.RequestLogger(c, fc.logger)
+	restaurantID := c.Param("restaurantId")
+	if restaurantID == "" {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrRestaurantIDRequired, nil))
+		return
+	}
+
+	userID, exists := middleware.GetEntityID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.ErrUserIDNotFound, nil))
+		return
+	}
+
+	if err := fc.store.Remove(userID, restaurantID); err != nil {
+		logger.WithError(err).WithField("userId", userID).Error("Failed to remove favorite")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.ErrFailedRemoveFavorite, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SuccessResponse(model.MsgFavoriteRemoved, gin.H{"restaurantId": restaurantID}))
+}
+
+// GetFavorites lists the authenticated user's favorite restaurants with their summary data
+func (fc *FavoritesController) GetFavorites(c *gin.Context) {
+	if fc.abortIfUnsupported(c) {
+		return
+	}
+	logger := middleware.RequestLogger(c, fc.logger)
+	userID, exists := middleware.GetEntityID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.ErrUserIDNotFound, nil))
+		return
+	}
+
+	restaurantIDs, err := fc.store.List(userID)
+	if err != nil {
+		logger.WithError(err).WithField("userId", userID).Error("Failed to list favorites")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.ErrFailedRetrieveFavorites, err))
+		return
+	}
+
+	ctx, cancel := fc.backendContext(c)
+	defer cancel()
+
+	// Fetch summaries concurrently, keeping the store's ordering
+	favorites := make([]*model.FavoriteRestaurant, len(restaurantIDs))
+	utils.FanOut(len(restaurantIDs), fc.fanOutLimit, func(i int) {
+		restaurantID := restaurantIDs[i]
+
+		restaurant, err := fc.restaurantClient.GetRestaurantByID(ctx, &restaurantPb.GetRestaurantByIDRequest{
+			RestaurantId: restaurantID,
+		})
+		if err != nil {
+			// Restaurants that can no longer be looked up are left out of the listing
+			logger.WithError(err).WithField("restaurantId", restaurantID).Warn("Failed to get favorite restaurant")
+			return
+		}
+
+		favorites[i] = &model.FavoriteRestaurant{
+			RestaurantID:   restaurantID,
+			RestaurantName: restaurant.RestaurantName,
+			PhoneNumber:    restaurant.PhoneNumber,
+			Address:        toModelAddress(restaurant.Address),
+			IsAvailable:    !restaurant.IsBanned,
+		}
+	})
+
+	result := make([]*model.FavoriteRestaurant, 0, len(favorites))
+	for _, favorite := range favorites {
+		if favorite != nil {
+			result = append(result, favorite)
+		}
+	}
+
+	c.JSON(http.StatusOK, model.SuccessResponse(model.MsgFavoritesRetrieved, result))
+}
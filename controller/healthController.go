@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// Backend statuses reported besides the health protocol's own serving statuses
+const (
+	// The backend answered but does not implement the health protocol, which
+	// still shows it is up
+	healthStatusReachable = "REACHABLE"
+	// The health check failed, e.g. the backend is down or timed out
+	healthStatusUnreachable = "UNREACHABLE"
+	// A required service has no connection
+	healthStatusNotConfigured = "NOT_CONFIGURED"
+)
+
+// HealthController reports the health of the backend services using the gRPC
+// health checking protocol, for load balancer probes, along with the state of
+// their circuit breakers
+type HealthController struct {
+	clients  map[string]healthpb.HealthClient
+	breakers func() map[string]string
+	required []string
+	timeout  time.Duration
+}
+
+func NewHealthController(conns map[string]grpc.ClientConnInterface, breakers func() map[string]string, required []string, timeout time.Duration) *HealthController {
+	clients := make(map[string]healthpb.HealthClient, len(conns))
+	for name, conn := range conns {
+		clients[name] = healthpb.NewHealthClient(conn)
+	}
+	return &HealthController{
+		clients:  clients,
+		breakers: breakers,
+		required: required,
+		timeout:  timeout,
+	}
+}
+
+// Health reports the status of every backend. The gateway itself is up if it
+// answers, so it always responds 200.
+func (hc *HealthController) Health(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "ok",
+		"services": hc.check(c),
+		"breakers": hc.breakers(),
+	})
+}
+
+// Ready reports the status of every backend, responding 503 unless all required
+// backends are healthy so load balancers stop sending traffic
+func (hc *HealthController) Ready(c *gin.Context) {
+	services := hc.check(c)
+	ready := true
+	for _, name := range hc.required {
+		if _, ok := services[name]; !ok {
+			services[name] = healthStatusNotConfigured
+		}
+		if !healthy(services[name]) {
+			ready = false
+		}
+	}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":   "unavailable",
+			"services": services,
+			"breakers": hc.breakers(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "ready",
+		"services": services,
+		"breakers": hc.breakers(),
+	})
+}
+
+// check queries every backend in parallel and returns their statuses by name
+func (hc *HealthController) check(c *gin.Context) map[string]string {
+	ctx, cancel := middleware.WithRequestTimeout(c, hc.timeout)
+	defer cancel()
+
+	var (
+		mutex    sync.Mutex
+		wg       sync.WaitGroup
+		statuses = make(map[string]string, len(hc.clients))
+	)
+	for name, client := range hc.clients {
+		wg.Add(1)
+		go func(name string, client healthpb.HealthClient) {
+			defer wg.Done()
+
+			result := healthStatusUnreachable
+			response, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+			switch {
+			case err == nil:
+				result = response.GetStatus().String()
+			case status.Code(err) == codes.Unimplemented:
+				result = healthStatusReachable
+			}
+
+			mutex.Lock()
+			statuses[name] = result
+			mutex.Unlock()
+		}(name, client)
+	}
+	wg.Wait()
+	return statuses
+}
+
+func healthy(state string) bool {
+	return state == healthpb.HealthCheckResponse_SERVING.String() || state == healthStatusReachable
+}
@@ -2,7 +2,15 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -10,8 +18,13 @@ import (
 	OrderCart "github.com/liju-github/CentralisedFoodbuddyMicroserviceProto/OrderCart"
 	Restaurant "github.com/liju-github/CentralisedFoodbuddyMicroserviceProto/Restaurant"
 	User "github.com/liju-github/CentralisedFoodbuddyMicroserviceProto/User"
+	config "github.com/liju-github/FoodBuddyAPIGateway/configs"
 	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
+	"github.com/liju-github/FoodBuddyAPIGateway/model"
+	"github.com/liju-github/FoodBuddyAPIGateway/utils"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type OrderCartController struct {
@@ -20,24 +33,304 @@ type OrderCartController struct {
 	restaurantClient Restaurant.RestaurantServiceClient
 	validator        *validator.Validate
 	logger           *logrus.Logger
+	notifier         *utils.WebhookNotifier
+	timeout          time.Duration
+
+	// Bound calls this controller makes to the User and Restaurant services
+	userTimeout       time.Duration
+	restaurantTimeout time.Duration
+
+	maxOrderItems         int
+	maxOrderTotalQuantity int
+
+	requireSameStateDelivery bool
+
+	defaultPrepTime     time.Duration
+	restaurantPrepTimes map[string]time.Duration
+
+	deliveryBaseFee       float64
+	deliveryFeePerKm      float64
+	maxDeliveryDistanceKm float64
+
+	schedule operatingSchedule
+
+	trendingCache  utils.Cache
+	trendingWindow time.Duration
+	trendingLimit  int
+
+	fanOutLimit int
+
+	restaurantNameCache utils.Cache
+
+	metricsCache  utils.Cache
+	metricsWindow time.Duration
+
+	orderCountsCache utils.Cache
+
+	commissionPercent    float64
+	maxEarningsRangeDays int
+
+	orderCodes utils.OrderCodeStore
 }
 
-func NewOrderCartController(orderCartClient OrderCart.OrderCartServiceClient, userClient User.UserServiceClient, restaurantClient Restaurant.RestaurantServiceClient) *OrderCartController {
+func NewOrderCartController(cfg config.Config, orderCartClient OrderCart.OrderCartServiceClient, userClient User.UserServiceClient, restaurantClient Restaurant.RestaurantServiceClient, notifier *utils.WebhookNotifier, trendingCache, restaurantNameCache, metricsCache, orderCountsCache utils.Cache, orderCodes utils.OrderCodeStore) *OrderCartController {
+	logger := logrus.New()
 	return &OrderCartController{
 		orderCartClient:  orderCartClient,
 		userClient:       userClient,
 		restaurantClient: restaurantClient,
 		validator:        validator.New(),
-		logger:           logrus.New(),
+		logger:           logger,
+		notifier:         notifier,
+		timeout:          cfg.OrderCartTimeout,
+
+		userTimeout:       cfg.UserTimeout,
+		restaurantTimeout: cfg.RestaurantTimeout,
+
+		maxOrderItems:         cfg.MaxOrderItems,
+		maxOrderTotalQuantity: cfg.MaxOrderTotalQuantity,
+
+		requireSameStateDelivery: cfg.RequireSameStateDelivery,
+
+		defaultPrepTime:     cfg.DefaultPrepTime,
+		restaurantPrepTimes: cfg.RestaurantPrepTimes,
+
+		deliveryBaseFee:       cfg.DeliveryBaseFee,
+		deliveryFeePerKm:      cfg.DeliveryFeePerKm,
+		maxDeliveryDistanceKm: cfg.MaxDeliveryDistanceKm,
+
+		schedule: loadOperatingHours(cfg, logger),
+
+		trendingCache:  trendingCache,
+		trendingWindow: cfg.TrendingWindow,
+		trendingLimit:  cfg.TrendingLimit,
+
+		fanOutLimit: cfg.MaxBackendConcurrency,
+
+		restaurantNameCache: restaurantNameCache,
+
+		metricsCache:  metricsCache,
+		metricsWindow: cfg.RestaurantMetricsWindow,
+
+		orderCountsCache: orderCountsCache,
+
+		commissionPercent:    cfg.PlatformCommissionPercent,
+		maxEarningsRangeDays: cfg.MaxEarningsRangeDays,
+
+		orderCodes: orderCodes,
+	}
+}
+
+// operatingSchedule holds the configured operating hours of the restaurants
+type operatingSchedule struct {
+	// A nil default means restaurants without their own hours are always open
+	defaultHours    *utils.OperatingHours
+	restaurantHours map[string]utils.OperatingHours
+	location        *time.Location
+}
+
+// loadOperatingHours parses the configured operating hours, logging and skipping
+// invalid entries
+func loadOperatingHours(cfg config.Config, logger *logrus.Logger) operatingSchedule {
+	var defaultHours *utils.OperatingHours
+	if cfg.DefaultOperatingHours != "" {
+		hours, err := utils.ParseOperatingHours(cfg.DefaultOperatingHours)
+		if err != nil {
+			logger.WithError(err).Error("Invalid default operating hours, ignoring")
+		} else {
+			defaultHours = &hours
+		}
+	}
+
+	restaurantHours := make(map[string]utils.OperatingHours)
+	for restaurantId, value := range cfg.RestaurantOperatingHours {
+		hours, err := utils.ParseOperatingHours(value)
+		if err != nil {
+			logger.WithError(err).WithField("restaurantId", restaurantId).Error("Invalid restaurant operating hours, ignoring")
+			continue
+		}
+		restaurantHours[restaurantId] = hours
+	}
+
+	location, err := time.LoadLocation(cfg.OperatingHoursTimezone)
+	if err != nil {
+		logger.WithError(err).Error("Invalid operating hours timezone, using local time")
+		location = time.Local
+	}
+	return operatingSchedule{
+		defaultHours:    defaultHours,
+		restaurantHours: restaurantHours,
+		location:        location,
+	}
+}
+
+// isOpenAt reports whether the restaurant is within its operating hours at t
+func (s operatingSchedule) isOpenAt(restaurantId string, t time.Time) bool {
+	hours, ok := s.restaurantHours[restaurantId]
+	if !ok {
+		if s.defaultHours == nil {
+			return true
+		}
+		hours = *s.defaultHours
+	}
+	return hours.Contains(t.In(s.location))
+}
+
+// backendContext returns a context for a backend call made on behalf of c, bounded by
+// the OrderCart service timeout and cancelled if the client disconnects
+func (oc *OrderCartController) backendContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	return middleware.WithRequestTimeout(c, oc.timeout)
+}
+
+// userContext is backendContext for a call to the User service
+func (oc *OrderCartController) userContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	return middleware.WithRequestTimeout(c, oc.userTimeout)
+}
+
+// restaurantContext is backendContext for a call to the Restaurant service
+func (oc *OrderCartController) restaurantContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	return middleware.WithRequestTimeout(c, oc.restaurantTimeout)
+}
+
+// OrderStatusAll is the status filter for orders in every status, which absent,
+// empty and "ALL" status queries are translated to. It is never sent to the OrderCart
+// service, which does not document what an empty status means: userOrders and
+// restaurantOrders ask for each known status instead.
+const OrderStatusAll = ""
+
+// orderStatuses lists the order statuses defined by the OrderCart service, which are
+// the statuses accepted as order list filters
+var orderStatuses = map[string]bool{
+	"PENDING":   true,
+	"ACCEPTED":  true,
+	"PREPARING": true,
+	"READY":     true,
+	"DELIVERED": true,
+	"CANCELLED": true,
+}
+
+// terminalOrderStatuses lists the statuses after which an order no longer changes
+var terminalOrderStatuses = map[string]bool{
+	"DELIVERED": true,
+	"CANCELLED": true,
+}
+
+// actionableOrderStatuses lists the statuses in which an order is waiting on the restaurant
+var actionableOrderStatuses = map[string]bool{
+	"PENDING":   true,
+	"ACCEPTED":  true,
+	"PREPARING": true,
+}
+
+// normalizeOrderStatus maps a status query value to the filter forwarded to the
+// backend. It reports false when the status is not a known order status.
+func normalizeOrderStatus(status string) (string, bool) {
+	status = strings.ToUpper(strings.TrimSpace(status))
+	if status == "" || status == "ALL" {
+		return OrderStatusAll, true
+	}
+	if !orderStatuses[status] {
+		return "", false
+	}
+	return status, true
+}
+
+// ordersInEveryStatus calls list once per known order status, concurrently, and
+// merges the results newest first. It fails if any of the calls fails.
+func (oc *OrderCartController) ordersInEveryStatus(list func(status string) ([]*OrderCart.Order, error)) ([]*OrderCart.Order, error) {
+	statuses := make([]string, 0, len(orderStatuses))
+	for status := range orderStatuses {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	pages := make([][]*OrderCart.Order, len(statuses))
+	errs := make([]error, len(statuses))
+	utils.FanOut(len(statuses), oc.fanOutLimit, func(i int) {
+		pages[i], errs[i] = list(statuses[i])
+	})
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	var orders []*OrderCart.Order
+	for _, page := range pages {
+		orders = append(orders, page...)
 	}
+	// Orders with an unparsable timestamp sort last
+	sort.SliceStable(orders, func(i, j int) bool {
+		createdI, _ := time.Parse(time.RFC3339, orders[i].CreatedAt)
+		createdJ, _ := time.Parse(time.RFC3339, orders[j].CreatedAt)
+		return createdI.After(createdJ)
+	})
+	return orders, nil
+}
+
+// orderTotals returns the order count and total amount of orders
+func orderTotals(orders []*OrderCart.Order) (int32, float64) {
+	var total float64
+	for _, order := range orders {
+		total += order.TotalAmount
+	}
+	return int32(len(orders)), total
+}
+
+// userOrders lists a user's orders, answering OrderStatusAll with ordersInEveryStatus
+func (oc *OrderCartController) userOrders(ctx context.Context, req *OrderCart.GetOrderDetailsAllRequest) (*OrderCart.GetOrderDetailsAllResponse, error) {
+	if req.Status != OrderStatusAll {
+		return oc.orderCartClient.GetOrderDetailsAll(ctx, req)
+	}
+
+	orders, err := oc.ordersInEveryStatus(func(status string) ([]*OrderCart.Order, error) {
+		response, err := oc.userOrders(ctx, &OrderCart.GetOrderDetailsAllRequest{
+			UserId:    req.UserId,
+			Status:    status,
+			StartDate: req.StartDate,
+			EndDate:   req.EndDate,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return response.Orders, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	totalOrders, totalAmount := orderTotals(orders)
+	return &OrderCart.GetOrderDetailsAllResponse{Orders: orders, TotalOrders: totalOrders, TotalAmount: totalAmount}, nil
+}
+
+// restaurantOrders lists a restaurant's orders, answering OrderStatusAll with
+// ordersInEveryStatus
+func (oc *OrderCartController) restaurantOrders(ctx context.Context, req *OrderCart.GetRestaurantOrdersRequest) (*OrderCart.GetRestaurantOrdersResponse, error) {
+	if req.Status != OrderStatusAll {
+		return oc.orderCartClient.GetRestaurantOrders(ctx, req)
+	}
+
+	orders, err := oc.ordersInEveryStatus(func(status string) ([]*OrderCart.Order, error) {
+		response, err := oc.restaurantOrders(ctx, &OrderCart.GetRestaurantOrdersRequest{
+			RestaurantId: req.RestaurantId,
+			Status:       status,
+			StartDate:    req.StartDate,
+			EndDate:      req.EndDate,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return response.Orders, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	totalOrders, totalAmount := orderTotals(orders)
+	return &OrderCart.GetRestaurantOrdersResponse{Orders: orders, TotalOrders: totalOrders, TotalAmount: totalAmount}, nil
 }
 
 // Cart Operations
 
 func (oc *OrderCartController) AddProductToCart(c *gin.Context) {
-	var req OrderCart.AddProductToCartRequest
-	if err := c.BindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	req, ok := bindJSON[OrderCart.AddProductToCartRequest](c, oc.logger)
+	if !ok {
 		return
 	}
 
@@ -49,11 +342,14 @@ func (oc *OrderCartController) AddProductToCart(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := oc.backendContext(c)
 	defer cancel()
 
-	response, err := oc.orderCartClient.AddProductToCart(ctx, &req)
+	response, err := oc.orderCartClient.AddProductToCart(ctx, req)
 	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -71,11 +367,14 @@ func (oc *OrderCartController) GetCartItems(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := oc.backendContext(c)
 	defer cancel()
 
 	response, err := oc.orderCartClient.GetCartItems(ctx, &req)
 	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -86,11 +385,14 @@ func (oc *OrderCartController) GetCartItems(c *gin.Context) {
 func (oc *OrderCartController) GetAllCarts(c *gin.Context) {
 	userId, _ := middleware.GetEntityID(c)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := oc.backendContext(c)
 	defer cancel()
 
 	response, err := oc.orderCartClient.GetAllCarts(ctx, &OrderCart.GetAllCartsRequest{UserId: userId})
 	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -98,10 +400,52 @@ func (oc *OrderCartController) GetAllCarts(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// GetCartCount returns only the number of items across the user's carts, for the
+// frequently polled cart badge. ?byRestaurant=true adds a count per restaurant.
+// The order service has no count query, so the carts are still fetched in full
+// but only the counts are sent to the client.
+func (oc *OrderCartController) GetCartCount(c *gin.Context) {
+	userId, _ := middleware.GetEntityID(c)
+
+	byRestaurant, err := strconv.ParseBool(c.DefaultQuery("byRestaurant", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "byRestaurant must be true or false"})
+		return
+	}
+
+	ctx, cancel := oc.backendContext(c)
+	defer cancel()
+
+	response, err := oc.orderCartClient.GetAllCarts(ctx, &OrderCart.GetAllCartsRequest{UserId: userId})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var count model.CartCount
+	if byRestaurant {
+		count.Restaurants = make(map[string]int32, len(response.Carts))
+	}
+	for _, cart := range response.Carts {
+		var items int32
+		for _, item := range cart.Items {
+			items += item.Quantity
+		}
+		count.TotalItems += items
+		if byRestaurant && items > 0 {
+			count.Restaurants[cart.RestaurantId] += items
+		}
+	}
+
+	c.JSON(http.StatusOK, count)
+}
+
 func (oc *OrderCartController) IncrementProductQuantity(c *gin.Context) {
-	var req OrderCart.IncrementProductQuantityRequest
-	if err := c.BindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	req, ok := bindJSON[OrderCart.IncrementProductQuantityRequest](c, oc.logger)
+	if !ok {
 		return
 	}
 
@@ -112,7 +456,7 @@ func (oc *OrderCartController) IncrementProductQuantity(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := oc.backendContext(c)
 	defer cancel()
 
 	// Get restaurant ID from product ID
@@ -120,13 +464,19 @@ func (oc *OrderCartController) IncrementProductQuantity(c *gin.Context) {
 		ProductId: req.ProductId,
 	})
 	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get restaurant ID: " + err.Error()})
 		return
 	}
 	req.RestaurantId = restIDResp.RestaurantId
 
-	response, err := oc.orderCartClient.IncrementProductQuantity(ctx, &req)
+	response, err := oc.orderCartClient.IncrementProductQuantity(ctx, req)
 	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -135,9 +485,8 @@ func (oc *OrderCartController) IncrementProductQuantity(c *gin.Context) {
 }
 
 func (oc *OrderCartController) DecrementProductQuantity(c *gin.Context) {
-	var req OrderCart.DecrementProductQuantityRequest
-	if err := c.BindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	req, ok := bindJSON[OrderCart.DecrementProductQuantityRequest](c, oc.logger)
+	if !ok {
 		return
 	}
 
@@ -148,7 +497,7 @@ func (oc *OrderCartController) DecrementProductQuantity(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := oc.backendContext(c)
 	defer cancel()
 
 	// Get restaurant ID from product ID
@@ -156,13 +505,19 @@ func (oc *OrderCartController) DecrementProductQuantity(c *gin.Context) {
 		ProductId: req.ProductId,
 	})
 	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get restaurant ID: " + err.Error()})
 		return
 	}
 	req.RestaurantId = restIDResp.RestaurantId
 
-	response, err := oc.orderCartClient.DecrementProductQuantity(ctx, &req)
+	response, err := oc.orderCartClient.DecrementProductQuantity(ctx, req)
 	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -171,9 +526,8 @@ func (oc *OrderCartController) DecrementProductQuantity(c *gin.Context) {
 }
 
 func (oc *OrderCartController) RemoveProductFromCart(c *gin.Context) {
-	var req OrderCart.RemoveProductFromCartRequest
-	if err := c.BindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	req, ok := bindJSON[OrderCart.RemoveProductFromCartRequest](c, oc.logger)
+	if !ok {
 		return
 	}
 
@@ -190,7 +544,7 @@ func (oc *OrderCartController) RemoveProductFromCart(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := oc.backendContext(c)
 	defer cancel()
 
 	// Get restaurant ID from product ID
@@ -198,13 +552,19 @@ func (oc *OrderCartController) RemoveProductFromCart(c *gin.Context) {
 		ProductId: req.ProductId,
 	})
 	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get restaurant ID: " + err.Error()})
 		return
 	}
 	req.RestaurantId = restIDResp.RestaurantId
 
-	response, err := oc.orderCartClient.RemoveProductFromCart(ctx, &req)
+	response, err := oc.orderCartClient.RemoveProductFromCart(ctx, req)
 	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -222,11 +582,14 @@ func (oc *OrderCartController) ClearCart(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := oc.backendContext(c)
 	defer cancel()
 
 	response, err := oc.orderCartClient.ClearCart(ctx, &req)
 	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -234,16 +597,339 @@ func (oc *OrderCartController) ClearCart(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// ValidateCart checks every item in the user's carts against current stock and
+// restaurant availability. An optional restaurantId query limits the check to one cart.
+func (oc *OrderCartController) ValidateCart(c *gin.Context) {
+	userId, _ := middleware.GetEntityID(c)
+	if userId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "userId is required"})
+		return
+	}
+	restaurantId := c.Query("restaurantId")
+
+	ctx, cancel := oc.backendContext(c)
+	defer cancel()
+
+	cartsResp, err := oc.orderCartClient.GetAllCarts(ctx, &OrderCart.GetAllCartsRequest{UserId: userId})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var items []*OrderCart.CartItem
+	restaurantStatus := make(map[string]string)
+	for _, cart := range cartsResp.Carts {
+		if restaurantId != "" && cart.RestaurantId != restaurantId {
+			continue
+		}
+		restaurantStatus[cart.RestaurantId] = ""
+		items = append(items, cart.Items...)
+	}
+
+	// Resolve each restaurant's availability once; an empty reason means available
+	for id := range restaurantStatus {
+		restResp, err := oc.restaurantClient.GetRestaurantByID(ctx, &Restaurant.GetRestaurantByIDRequest{RestaurantId: id})
+		switch {
+		case err != nil:
+			restaurantStatus[id] = "Restaurant could not be verified"
+		case restResp.IsBanned:
+			restaurantStatus[id] = "Restaurant is currently unavailable"
+		}
+	}
+
+	report := model.CartValidationReport{
+		IsValid: true,
+		Items:   make([]model.CartItemValidation, len(items)),
+	}
+
+	utils.FanOut(len(items), oc.fanOutLimit, func(i int) {
+		item := items[i]
+
+		result := model.CartItemValidation{
+			ProductID:    item.ProductId,
+			RestaurantID: item.RestaurantId,
+			ProductName:  item.ProductName,
+			Quantity:     item.Quantity,
+		}
+
+		stockResp, err := oc.restaurantClient.GetStockByProductID(ctx, &Restaurant.GetStockByProductIDRequest{ProductId: item.ProductId})
+		switch {
+		case err != nil:
+			result.Reason = "Product is no longer available"
+		case restaurantStatus[item.RestaurantId] != "":
+			result.AvailableStock = stockResp.Stock
+			result.Reason = restaurantStatus[item.RestaurantId]
+		case stockResp.Stock < item.Quantity:
+			result.AvailableStock = stockResp.Stock
+			result.Reason = fmt.Sprintf("Only %d left in stock", stockResp.Stock)
+		default:
+			result.AvailableStock = stockResp.Stock
+			result.IsValid = true
+		}
+
+		report.Items[i] = result
+	})
+
+	for _, item := range report.Items {
+		if !item.IsValid {
+			report.IsValid = false
+			break
+		}
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetCartSummary returns per restaurant item counts and subtotals computed from
+// current product prices, plus a grand total across the user's carts
+func (oc *OrderCartController) GetCartSummary(c *gin.Context) {
+	userId, _ := middleware.GetEntityID(c)
+	if userId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "userId is required"})
+		return
+	}
+
+	ctx, cancel := oc.backendContext(c)
+	defer cancel()
+
+	cartsResp, err := oc.orderCartClient.GetAllCarts(ctx, &OrderCart.GetAllCartsRequest{UserId: userId})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	summary := model.CartSummary{
+		Restaurants: make([]model.CartRestaurantSummary, len(cartsResp.Carts)),
+	}
+	var failures failureCollector
+
+	utils.FanOut(len(cartsResp.Carts), oc.fanOutLimit, func(i int) {
+		cart := cartsResp.Carts[i]
+
+		result := model.CartRestaurantSummary{
+			RestaurantID:   cart.RestaurantId,
+			RestaurantName: cart.RestaurantName,
+		}
+
+		restResp, err := oc.restaurantClient.GetRestaurantByID(ctx, &Restaurant.GetRestaurantByIDRequest{RestaurantId: cart.RestaurantId})
+		if err != nil {
+			failures.add("restaurant:"+cart.RestaurantId, err)
+		}
+		result.IsAvailable = err == nil && !restResp.IsBanned
+
+		for _, item := range cart.Items {
+			// Prefer the current product price, falling back to the price stored in the cart
+			price := item.Price
+			productResp, err := oc.restaurantClient.GetProductByID(ctx, &Restaurant.GetProductByIDRequest{ProductId: item.ProductId})
+			switch {
+			case err != nil:
+				failures.add("product:"+item.ProductId, err)
+			case productResp.Product != nil:
+				price = productResp.Product.Price
+			}
+
+			result.ItemCount += item.Quantity
+			result.Subtotal += price * float64(item.Quantity)
+		}
+
+		summary.Restaurants[i] = result
+	})
+
+	for _, restaurant := range summary.Restaurants {
+		summary.TotalItems += restaurant.ItemCount
+		if restaurant.IsAvailable {
+			summary.GrandTotal += restaurant.Subtotal
+		}
+	}
+	summary.PartialResult = failures.result()
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// MergeCart moves the items of a guest cart into the authenticated user's cart.
+// Quantities of items present in both carts are summed, then capped by product
+// stock and the order size limits. A guest restaurant cart is cleared once all of
+// its items have been processed, unless adding one of them failed.
+func (oc *OrderCartController) MergeCart(c *gin.Context) {
+	req, ok := bindJSON[model.MergeCartRequest](c, oc.logger)
+	if !ok {
+		return
+	}
+	userId, _ := middleware.GetEntityID(c)
+
+	if userId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "userId is required"})
+		return
+	}
+	if req.GuestCartID == userId {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "guestCartId must differ from the authenticated user"})
+		return
+	}
+
+	ctx, cancel := oc.backendContext(c)
+	defer cancel()
+
+	// Guest carts are keyed by an opaque identifier held by the guest client. Refuse
+	// identifiers of registered accounts so one user cannot drain another's cart.
+	profile, err := oc.userClient.GetProfile(ctx, &User.GetProfileRequest{UserId: req.GuestCartID})
+	if err != nil && status.Code(err) != codes.NotFound {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err == nil && profile.GetUserId() != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "guestCartId belongs to a registered account"})
+		return
+	}
+
+	guestCarts, err := oc.orderCartClient.GetAllCarts(ctx, &OrderCart.GetAllCartsRequest{UserId: req.GuestCartID})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	userCarts, err := oc.orderCartClient.GetAllCarts(ctx, &OrderCart.GetAllCartsRequest{UserId: userId})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	existing := make(map[string]map[string]int32)
+	for _, cart := range userCarts.Carts {
+		quantities := make(map[string]int32)
+		for _, item := range cart.Items {
+			quantities[item.ProductId] += item.Quantity
+		}
+		existing[cart.RestaurantId] = quantities
+	}
+
+	result := model.CartMergeResult{
+		Merged:  []model.CartMergeItem{},
+		Skipped: []model.CartMergeItem{},
+	}
+
+	for _, cart := range guestCarts.Carts {
+		quantities := existing[cart.RestaurantId]
+		if quantities == nil {
+			quantities = make(map[string]int32)
+		}
+		var totalQuantity int32
+		for _, quantity := range quantities {
+			totalQuantity += quantity
+		}
+
+		addFailed := false
+		for _, item := range cart.Items {
+			merge := model.CartMergeItem{
+				ProductID:        item.ProductId,
+				RestaurantID:     cart.RestaurantId,
+				GuestQuantity:    item.Quantity,
+				PreviousQuantity: quantities[item.ProductId],
+			}
+
+			add, reason := oc.mergeQuantity(ctx, item, quantities, totalQuantity)
+			if add <= 0 {
+				merge.Reason = reason
+				result.Skipped = append(result.Skipped, merge)
+				continue
+			}
+
+			// The OrderCart service adds the quantity to any existing cart line
+			_, err := oc.orderCartClient.AddProductToCart(ctx, &OrderCart.AddProductToCartRequest{
+				UserId:    userId,
+				ProductId: item.ProductId,
+				Quantity:  add,
+			})
+			if err != nil {
+				oc.logger.WithFields(logrus.Fields{
+					"userId":    userId,
+					"productId": item.ProductId,
+					"error":     err.Error(),
+				}).Error("Failed to merge guest cart item")
+				addFailed = true
+				merge.Reason = "failed to add product to cart"
+				result.Skipped = append(result.Skipped, merge)
+				continue
+			}
+
+			quantities[item.ProductId] += add
+			totalQuantity += add
+			merge.AddedQuantity = add
+			merge.Reason = reason
+			result.Merged = append(result.Merged, merge)
+		}
+
+		if addFailed {
+			continue
+		}
+		if _, err := oc.orderCartClient.ClearCart(ctx, &OrderCart.ClearCartRequest{
+			UserId:       req.GuestCartID,
+			RestaurantId: cart.RestaurantId,
+		}); err != nil {
+			oc.logger.WithFields(logrus.Fields{
+				"guestCartId":  req.GuestCartID,
+				"restaurantId": cart.RestaurantId,
+				"error":        err.Error(),
+			}).Error("Failed to clear merged guest cart")
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// mergeQuantity returns how much of a guest cart item can be added to a user's
+// restaurant cart holding quantities, with totalQuantity items in all, and why the
+// quantity was reduced or refused when it was
+func (oc *OrderCartController) mergeQuantity(ctx context.Context, item *OrderCart.CartItem, quantities map[string]int32, totalQuantity int32) (int32, string) {
+	productResp, err := oc.restaurantClient.GetProductByID(ctx, &Restaurant.GetProductByIDRequest{ProductId: item.ProductId})
+	if err != nil || productResp.Product == nil {
+		return 0, "product is no longer available"
+	}
+
+	previous, inCart := quantities[item.ProductId]
+	if !inCart && oc.maxOrderItems > 0 && len(quantities) >= oc.maxOrderItems {
+		return 0, fmt.Sprintf("cart already has the maximum of %d distinct items", oc.maxOrderItems)
+	}
+
+	add, reason := item.Quantity, ""
+	if available := productResp.Product.Stock - previous; add > available {
+		add, reason = available, "limited by available stock"
+	}
+	if oc.maxOrderTotalQuantity > 0 {
+		if room := int32(oc.maxOrderTotalQuantity) - totalQuantity; add > room {
+			add, reason = room, fmt.Sprintf("limited by the maximum of %d items per order", oc.maxOrderTotalQuantity)
+		}
+	}
+	return add, reason
+}
+
 // Order Operations
 
 func (oc *OrderCartController) PlaceOrderByRestID(c *gin.Context) {
 	// 1. Parse and validate request
-	var req OrderCart.PlaceOrderByRestIDRequest
-	if err := c.BindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	body, ok := bindJSON[model.PlaceOrderRequest](c, oc.logger)
+	if !ok {
 		return
 	}
 
+	req := &OrderCart.PlaceOrderByRestIDRequest{
+		RestaurantId:      body.RestaurantID,
+		DeliveryAddressId: body.DeliveryAddressID,
+	}
 	req.UserId, _ = middleware.GetEntityID(c)
 
 	// 2. Validate required fields
@@ -251,16 +937,34 @@ func (oc *OrderCartController) PlaceOrderByRestID(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "All fields including delivery address are required"})
 		return
 	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// The order service has no field for a delivery time, so a scheduled order
+	// cannot be forwarded without it being placed for immediate delivery. Times that
+	// could never be accepted still get 400, and the rest are turned away before any
+	// backend call is made.
+	if body.ScheduledFor != nil {
+		if !body.ScheduledFor.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "scheduledFor must be in the future"})
+			return
+		}
+		if !oc.schedule.isOpenAt(req.RestaurantId, *body.ScheduledFor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Restaurant is closed at the scheduled time"})
+			return
+		}
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Scheduled orders are not supported by the order service yet"})
+		return
+	}
 
 	// 3. Validate user's address
-	addrResp, err := oc.userClient.ValidateUserAddress(ctx, &User.ValidateUserAddressRequest{
+	userCtx, cancelUser := oc.userContext(c)
+	defer cancelUser()
+	addrResp, err := oc.userClient.ValidateUserAddress(userCtx, &User.ValidateUserAddressRequest{
 		UserId:    req.UserId,
 		AddressId: req.DeliveryAddressId,
 	})
 	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate delivery address: " + err.Error()})
 		return
 	}
@@ -270,10 +974,15 @@ func (oc *OrderCartController) PlaceOrderByRestID(c *gin.Context) {
 	}
 
 	// 4. Check restaurant status
-	restResp, err := oc.restaurantClient.GetRestaurantByID(ctx, &Restaurant.GetRestaurantByIDRequest{
+	restaurantCtx, cancelRestaurant := oc.restaurantContext(c)
+	defer cancelRestaurant()
+	restResp, err := oc.restaurantClient.GetRestaurantByID(restaurantCtx, &Restaurant.GetRestaurantByIDRequest{
 		RestaurantId: req.RestaurantId,
 	})
 	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get restaurant details: " + err.Error()})
 		return
 	}
@@ -281,73 +990,177 @@ func (oc *OrderCartController) PlaceOrderByRestID(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Restaurant is currently unavailable"})
 		return
 	}
+	if oc.requireSameStateDelivery && !sameState(addrResp.GetAddress().GetState(), restResp.GetAddress().GetState()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Restaurant only delivers within %s", restResp.GetAddress().GetState())})
+		return
+	}
+
+	// 5. Enforce order size limits on the cart being ordered
+	ctx, cancel := oc.backendContext(c)
+	defer cancel()
+	cartResp, err := oc.orderCartClient.GetCartItems(ctx, &OrderCart.GetCartItemsRequest{
+		UserId:       req.UserId,
+		RestaurantId: req.RestaurantId,
+	})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart items: " + err.Error()})
+		return
+	}
+	if oc.maxOrderItems > 0 && len(cartResp.Items) > oc.maxOrderItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("An order can contain at most %d distinct items, cart has %d", oc.maxOrderItems, len(cartResp.Items))})
+		return
+	}
+	totalQuantity := 0
+	for _, item := range cartResp.Items {
+		totalQuantity += int(item.Quantity)
+	}
+	if oc.maxOrderTotalQuantity > 0 && totalQuantity > oc.maxOrderTotalQuantity {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("An order can contain at most %d items in total, cart has %d", oc.maxOrderTotalQuantity, totalQuantity)})
+		return
+	}
 
-	// 5. Place the order
-	response, err := oc.orderCartClient.PlaceOrderByRestID(ctx, &req)
+	// 6. Place the order
+	response, err := oc.orderCartClient.PlaceOrderByRestID(ctx, req)
 	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 6. Return success response
-	c.JSON(http.StatusOK, gin.H{
-		"success": response.Success,
-		"orderId": response.OrderId,
-		"message": response.Message,
-		"order":   response.Order,
+	// 7. Notify the restaurant's POS integration
+	oc.notifier.Notify(utils.WebhookEvent{
+		Event:        utils.EventOrderPlaced,
+		RestaurantID: req.RestaurantId,
+		OrderID:      response.OrderId,
+		Status:       response.GetOrder().GetOrderStatus(),
+		Data:         response.Order,
+	})
+
+	// 8. Assign the short code support can look the order up by. The order is
+	// already placed, so failing to assign one is not an error for the client.
+	var orderCode string
+	if response.OrderId != "" {
+		orderCode, err = oc.orderCodes.Assign(response.OrderId, req.UserId)
+		if err != nil {
+			middleware.RequestLogger(c, oc.logger).WithError(err).WithField("orderId", response.OrderId).Error("Failed to assign order code")
+		}
+	}
+
+	// 9. Return success response
+	if response.OrderId != "" {
+		c.Header("Location", "/api/orders/details?orderId="+url.QueryEscape(response.OrderId))
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success":   response.Success,
+		"orderId":   response.OrderId,
+		"orderCode": orderCode,
+		"message":   response.Message,
+		"order":     response.Order,
 	})
 }
 
+// sameState reports whether two address states match, ignoring case and surrounding
+// whitespace. A missing state on either side is not treated as a mismatch.
+func sameState(a, b string) bool {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	if a == "" || b == "" {
+		return true
+	}
+	return strings.EqualFold(a, b)
+}
+
 func (oc *OrderCartController) GetOrderDetailsAll(c *gin.Context) {
 	var req OrderCart.GetOrderDetailsAllRequest
 	req.UserId, _ = middleware.GetEntityID(c)
-	req.Status = c.Query("status")
 
 	if req.UserId == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "userId is required"})
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	status, ok := normalizeOrderStatus(c.Query("status"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order status"})
+		return
+	}
+	req.Status = status
+
+	ctx, cancel := oc.backendContext(c)
 	defer cancel()
 
-	response, err := oc.orderCartClient.GetOrderDetailsAll(ctx, &req)
+	response, err := oc.userOrders(ctx, &req)
 	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
-}
+	var failures failureCollector
+	oc.enrichRestaurantNames(ctx, response.Orders, &failures)
 
-func (oc *OrderCartController) GetOrderDetailsByID(c *gin.Context) {
-	var req OrderCart.GetOrderDetailsByIDRequest
-	req.OrderId = c.Query("orderId")
-	req.UserId, _ = middleware.GetEntityID(c)
+	c.JSON(http.StatusOK, struct {
+		*OrderCart.GetOrderDetailsAllResponse
+		model.PartialResult
+	}{response, failures.result()})
+}
 
-	if req.OrderId == "" || req.UserId == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "orderId and userId are required"})
+// GetActiveOrder returns the user's most recent order that has not reached a terminal
+// status, or a null order when there is none
+func (oc *OrderCartController) GetActiveOrder(c *gin.Context) {
+	userId, _ := middleware.GetEntityID(c)
+	if userId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "userId is required"})
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := oc.backendContext(c)
 	defer cancel()
 
-	response, err := oc.orderCartClient.GetOrderDetailsByID(ctx, &req)
+	response, err := oc.userOrders(ctx, &OrderCart.GetOrderDetailsAllRequest{
+		UserId: userId,
+		Status: OrderStatusAll,
+	})
 	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
-}
+	var active *OrderCart.Order
+	var activeAt time.Time
+	for _, order := range response.Orders {
+		if terminalOrderStatuses[strings.ToUpper(order.OrderStatus)] {
+			continue
+		}
+		// Orders with an unparsable timestamp sort as the oldest
+		createdAt, _ := time.Parse(time.RFC3339, order.CreatedAt)
+		if active == nil || createdAt.After(activeAt) {
+			active, activeAt = order, createdAt
+		}
+	}
 
-func (oc *OrderCartController) CancelOrder(c *gin.Context) {
-	var req OrderCart.CancelOrderRequest
-	if err := c.BindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	var failures failureCollector
+	if active != nil {
+		oc.enrichRestaurantNames(ctx, []*OrderCart.Order{active}, &failures)
 	}
+	c.JSON(http.StatusOK, struct {
+		Order *OrderCart.Order `json:"order"`
+		model.PartialResult
+	}{active, failures.result()})
+}
+
+func (oc *OrderCartController) GetOrderDetailsByID(c *gin.Context) {
+	var req OrderCart.GetOrderDetailsByIDRequest
+	req.OrderId = c.Query("orderId")
 	req.UserId, _ = middleware.GetEntityID(c)
 
 	if req.OrderId == "" || req.UserId == "" {
@@ -355,21 +1168,731 @@ func (oc *OrderCartController) CancelOrder(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := oc.backendContext(c)
 	defer cancel()
 
-	response, err := oc.orderCartClient.CancelOrder(ctx, &req)
+	response, err := oc.orderCartClient.GetOrderDetailsByID(ctx, &req)
 	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	var failures failureCollector
+	if response.Order != nil {
+		oc.enrichRestaurantNames(ctx, []*OrderCart.Order{response.Order}, &failures)
+	}
+
+	c.JSON(http.StatusOK, struct {
+		*OrderCart.GetOrderDetailsByIDResponse
+		model.PartialResult
+	}{response, failures.result()})
+}
+
+// GetOrderByCode resolves the short code assigned at placement to the order's
+// details. Users may only look up their own orders, admins any order.
+func (oc *OrderCartController) GetOrderByCode(c *gin.Context) {
+	code := utils.NormalizeOrderCode(c.Query("code"))
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+
+	entry, found, err := oc.orderCodes.Lookup(code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	callerId, _ := middleware.GetEntityID(c)
+	role, _ := middleware.GetEntityRole(c)
+	// Another user's code is reported as unknown so codes cannot be probed
+	if !found || (role != middleware.RoleAdmin && entry.UserID != callerId) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No order found for this code"})
+		return
+	}
+
+	ctx, cancel := oc.backendContext(c)
+	defer cancel()
+
+	response, err := oc.orderCartClient.GetOrderDetailsByID(ctx, &OrderCart.GetOrderDetailsByIDRequest{
+		OrderId: entry.OrderID,
+		UserId:  entry.UserID,
+	})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var failures failureCollector
+	if response.Order != nil {
+		oc.enrichRestaurantNames(ctx, []*OrderCart.Order{response.Order}, &failures)
+	}
+
+	c.JSON(http.StatusOK, struct {
+		OrderCode string `json:"orderCode"`
+		*OrderCart.GetOrderDetailsByIDResponse
+		model.PartialResult
+	}{entry.Code, response, failures.result()})
+}
+
+// enrichRestaurantNames fills in missing restaurant names on orders. Each distinct
+// restaurant is looked up once, and names are cached across requests. Lookups that
+// fail leave the name empty and are recorded in failures rather than failing the request.
+func (oc *OrderCartController) enrichRestaurantNames(ctx context.Context, orders []*OrderCart.Order, failures *failureCollector) {
+	names := make(map[string]string)
+	var missing []string
+	for _, order := range orders {
+		if order.RestaurantName != "" || order.RestaurantId == "" {
+			continue
+		}
+		if _, seen := names[order.RestaurantId]; seen {
+			continue
+		}
+		if cached, ok := oc.restaurantNameCache.Get(order.RestaurantId); ok {
+			names[order.RestaurantId] = cached.(string)
+			continue
+		}
+		names[order.RestaurantId] = ""
+		missing = append(missing, order.RestaurantId)
+	}
+
+	resolved := make([]string, len(missing))
+	utils.FanOut(len(missing), oc.fanOutLimit, func(i int) {
+		restResp, err := oc.restaurantClient.GetRestaurantByID(ctx, &Restaurant.GetRestaurantByIDRequest{RestaurantId: missing[i]})
+		if err != nil {
+			oc.logger.WithError(err).WithField("restaurantId", missing[i]).Warn("Failed to resolve restaurant name")
+			failures.add("restaurant:"+missing[i], err)
+			return
+		}
+		resolved[i] = restResp.RestaurantName
+	})
+	for i, restaurantId := range missing {
+		if resolved[i] != "" {
+			names[restaurantId] = resolved[i]
+			oc.restaurantNameCache.Set(restaurantId, resolved[i])
+		}
+	}
+
+	for _, order := range orders {
+		if order.RestaurantName == "" {
+			order.RestaurantName = names[order.RestaurantId]
+		}
+	}
+}
+
+func (oc *OrderCartController) CancelOrder(c *gin.Context) {
+	req, ok := bindJSON[OrderCart.CancelOrderRequest](c, oc.logger)
+	if !ok {
+		return
+	}
+	req.UserId, _ = middleware.GetEntityID(c)
+
+	if req.OrderId == "" || req.UserId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "orderId and userId are required"})
+		return
+	}
+
+	ctx, cancel := oc.backendContext(c)
+	defer cancel()
+
+	response, err := oc.orderCartClient.CancelOrder(ctx, req)
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	oc.notifyOrderCancelled(c, req.OrderId, req.UserId, response.CancelReason)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateOrderAddress changes the delivery address of an order the restaurant has not
+// accepted yet. The order service has no RPC to change an order's address, so after
+// the ownership, status and address checks the request is answered with 501.
+func (oc *OrderCartController) UpdateOrderAddress(c *gin.Context) {
+	req, ok := bindJSON[model.UpdateOrderAddressRequest](c, oc.logger)
+	if !ok {
+		return
+	}
+	orderId := c.Param("orderId")
+	userId, _ := middleware.GetEntityID(c)
+
+	if orderId == "" || userId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "orderId and userId are required"})
+		return
+	}
+
+	ctx, cancel := oc.backendContext(c)
+	defer cancel()
+
+	// Orders are looked up by user, so another user's order is not found
+	orderResp, err := oc.orderCartClient.GetOrderDetailsByID(ctx, &OrderCart.GetOrderDetailsByIDRequest{
+		OrderId: orderId,
+		UserId:  userId,
+	})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if orderResp.GetOrder().GetOrderStatus() != "PENDING" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Delivery address can only be changed before the restaurant accepts the order"})
+		return
+	}
+
+	addrResp, err := oc.userClient.ValidateUserAddress(ctx, &User.ValidateUserAddressRequest{
+		UserId:    userId,
+		AddressId: req.AddressID,
+	})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate delivery address: " + err.Error()})
+		return
+	}
+	if !addrResp.IsValid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery address"})
+		return
+	}
+
+	c.JSON(http.StatusNotImplemented, gin.H{"error": "Changing the delivery address is not supported by the order service yet"})
+}
+
+// GetOrderETA estimates when an order will be delivered from the restaurant's prep
+// time and the approximate distance to the delivery address
+func (oc *OrderCartController) GetOrderETA(c *gin.Context) {
+	orderId := c.Param("orderId")
+	userId, _ := middleware.GetEntityID(c)
+
+	if orderId == "" || userId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "orderId and userId are required"})
+		return
+	}
+
+	ctx, cancel := oc.backendContext(c)
+	defer cancel()
+
+	response, err := oc.orderCartClient.GetOrderDetailsByID(ctx, &OrderCart.GetOrderDetailsByIDRequest{
+		OrderId: orderId,
+		UserId:  userId,
+	})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	order := response.GetOrder()
+	switch order.GetOrderStatus() {
+	case "DELIVERED", "CANCELLED":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Order is no longer in progress"})
+		return
+	}
+
+	prepTime, ok := oc.restaurantPrepTimes[order.GetRestaurantId()]
+	if !ok {
+		prepTime = oc.defaultPrepTime
+	}
+
+	distanceKm := utils.EstimateDistanceKm(order.GetRestaurantAddress().GetPincode(), order.GetDeliveryAddress().GetPincode())
+	travelTime := utils.EstimateTravelTime(distanceKm)
+	total := prepTime + travelTime
+
+	// Count from when the order was placed when the backend timestamp is parseable
+	start := time.Now()
+	if createdAt, err := time.Parse(time.RFC3339, order.GetCreatedAt()); err == nil {
+		start = createdAt
+	}
+
+	c.JSON(http.StatusOK, model.DeliveryEstimate{
+		OrderID:       orderId,
+		OrderStatus:   order.GetOrderStatus(),
+		EstimatedAt:   start.Add(total).UTC().Format(time.RFC3339),
+		MinMinutes:    int(total.Minutes() * 0.8),
+		MaxMinutes:    int(total.Minutes() * 1.2),
+		PrepMinutes:   int(prepTime.Minutes()),
+		TravelMinutes: int(travelTime.Minutes()),
+		DistanceKm:    distanceKm,
+	})
+}
+
+// GetDeliveryFee quotes the delivery fee from a restaurant to one of the user's
+// addresses, using the same pincode based distance estimate as GetOrderETA
+func (oc *OrderCartController) GetDeliveryFee(c *gin.Context) {
+	restaurantId := c.Query("restaurantId")
+	addressId := c.Query("addressId")
+	userId, _ := middleware.GetEntityID(c)
+
+	if restaurantId == "" || addressId == "" || userId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "restaurantId and addressId are required"})
+		return
+	}
+
+	ctx, cancel := oc.backendContext(c)
+	defer cancel()
+
+	// Validating against the user ID also confirms the address belongs to them
+	addrResp, err := oc.userClient.ValidateUserAddress(ctx, &User.ValidateUserAddressRequest{
+		UserId:    userId,
+		AddressId: addressId,
+	})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate delivery address: " + err.Error()})
+		return
+	}
+	if !addrResp.IsValid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery address"})
+		return
+	}
+
+	restResp, err := oc.restaurantClient.GetRestaurantByID(ctx, &Restaurant.GetRestaurantByIDRequest{
+		RestaurantId: restaurantId,
+	})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get restaurant details: " + err.Error()})
+		return
+	}
+
+	distanceKm := utils.EstimateDistanceKm(restResp.GetAddress().GetPincode(), addrResp.GetAddress().GetPincode())
+	if oc.maxDeliveryDistanceKm > 0 && distanceKm > oc.maxDeliveryDistanceKm {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Address is outside the restaurant's delivery area"})
+		return
+	}
+
+	distanceFee := distanceKm * oc.deliveryFeePerKm
+	c.JSON(http.StatusOK, model.DeliveryFee{
+		RestaurantID: restaurantId,
+		AddressID:    addressId,
+		DistanceKm:   distanceKm,
+		BaseFee:      oc.deliveryBaseFee,
+		DistanceFee:  distanceFee,
+		TotalFee:     oc.deliveryBaseFee + distanceFee,
+	})
+}
+
+// GetRestaurantImpact reports the restaurant's pending orders, the user carts that
+// reference it and its product count, so admins can gauge a moderation action first
+func (oc *OrderCartController) GetRestaurantImpact(c *gin.Context) {
+	restaurantId := c.Param("restaurantId")
+	if restaurantId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "restaurantId is required"})
+		return
+	}
+
+	ctx, cancel := oc.backendContext(c)
+	defer cancel()
+
+	impact := model.RestaurantImpact{RestaurantID: restaurantId}
+	var failures failureCollector
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+
+	go func() {
+		defer wg.Done()
+		ordersResp, err := oc.restaurantOrders(ctx, &OrderCart.GetRestaurantOrdersRequest{RestaurantId: restaurantId})
+		if err != nil {
+			failures.add("pendingOrders", err)
+			return
+		}
+		for _, order := range ordersResp.Orders {
+			if order.OrderStatus != "DELIVERED" && order.OrderStatus != "CANCELLED" {
+				impact.PendingOrders++
+			}
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		productsResp, err := oc.restaurantClient.GetRestaurantProductsByID(ctx, &Restaurant.GetRestaurantProductsByIDRequest{RestaurantId: restaurantId})
+		if err != nil {
+			failures.add("products", err)
+			return
+		}
+		impact.Products = len(productsResp.Products)
+	}()
+
+	go func() {
+		defer wg.Done()
+		count, err := oc.countCartsForRestaurant(ctx, restaurantId)
+		if err != nil {
+			failures.add("activeCarts", err)
+			return
+		}
+		impact.ActiveCarts = count
+	}()
+
+	wg.Wait()
+	impact.PartialResult = failures.result()
+
+	c.JSON(http.StatusOK, impact)
+}
+
+// countCartsForRestaurant counts users with a non-empty cart at the restaurant. The
+// cart service has no cross-user query, so every user's cart is checked.
+func (oc *OrderCartController) countCartsForRestaurant(ctx context.Context, restaurantId string) (int, error) {
+	usersResp, err := oc.userClient.GetAllUsers(ctx, &User.GetAllUsersRequest{})
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	utils.FanOut(len(usersResp.Users), oc.fanOutLimit, func(i int) {
+		cartResp, err := oc.orderCartClient.GetCartByRestaurant(ctx, &OrderCart.GetCartByRestaurantRequest{
+			UserId:       usersResp.Users[i].UserId,
+			RestaurantId: restaurantId,
+		})
+		if err == nil && len(cartResp.Items) > 0 {
+			atomic.AddInt64(&count, 1)
+		}
+	})
+
+	return int(count), nil
+}
+
+// GetTrendingProducts returns the most ordered products over the configured recent
+// window. An optional limit query narrows the list; larger limits are clamped to
+// the configured cap.
+func (oc *OrderCartController) GetTrendingProducts(c *gin.Context) {
+	limit, ok := parseLimit(c, oc.trendingLimit, oc.trendingLimit)
+	if !ok {
+		return
+	}
+
+	const cacheKey = "trending"
+	if cached, ok := oc.trendingCache.Get(cacheKey); ok {
+		c.Header("X-Cache", "HIT")
+		trending := cached.([]*model.TrendingProduct)
+		c.JSON(http.StatusOK, trending[:min(limit, len(trending))])
+		return
+	}
+
+	trending, err := oc.computeTrendingProducts(c)
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	oc.trendingCache.Set(cacheKey, trending)
+
+	c.Header("X-Cache", "MISS")
+	c.JSON(http.StatusOK, trending[:min(limit, len(trending))])
+}
+
+// computeTrendingProducts ranks products by quantity ordered within the trending
+// window. The order service has no aggregate query, so every restaurant's orders
+// are fetched concurrently and tallied in the gateway.
+func (oc *OrderCartController) computeTrendingProducts(c *gin.Context) ([]*model.TrendingProduct, error) {
+	ctx, cancel := oc.backendContext(c)
+	defer cancel()
+
+	restaurantsResp, err := oc.restaurantClient.GetAllRestaurantWithProducts(ctx, &Restaurant.GetAllRestaurantAndProductsRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	since := time.Now().Add(-oc.trendingWindow)
+	products := make(map[string]*model.TrendingProduct)
+	var mu sync.Mutex
+	utils.FanOut(len(restaurantsResp.Restaurants), oc.fanOutLimit, func(i int) {
+		restaurantId := restaurantsResp.Restaurants[i].RestaurantId
+
+		ordersResp, err := oc.restaurantOrders(ctx, &OrderCart.GetRestaurantOrdersRequest{RestaurantId: restaurantId})
+		if err != nil {
+			oc.logger.WithError(err).WithField("restaurantId", restaurantId).Warn("Failed to get restaurant orders for trending products")
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, order := range ordersResp.Orders {
+			if order.OrderStatus == "CANCELLED" {
+				continue
+			}
+			if !createdSince(order, since) {
+				continue
+			}
+			for _, item := range order.Items {
+				product, ok := products[item.ProductId]
+				if !ok {
+					product = &model.TrendingProduct{
+						ProductID:      item.ProductId,
+						ProductName:    item.ProductName,
+						Category:       item.Category,
+						Price:          item.Price,
+						RestaurantID:   order.RestaurantId,
+						RestaurantName: order.RestaurantName,
+					}
+					products[item.ProductId] = product
+				}
+				product.OrderCount++
+				product.QuantitySold += item.Quantity
+			}
+		}
+	})
+
+	trending := make([]*model.TrendingProduct, 0, len(products))
+	for _, product := range products {
+		trending = append(trending, product)
+	}
+	sort.Slice(trending, func(i, j int) bool {
+		if trending[i].QuantitySold != trending[j].QuantitySold {
+			return trending[i].QuantitySold > trending[j].QuantitySold
+		}
+		return trending[i].ProductID < trending[j].ProductID
+	})
+	if len(trending) > oc.trendingLimit {
+		trending = trending[:oc.trendingLimit]
+	}
+	return trending, nil
+}
+
+// createdSince reports whether the order was placed at or after since. Orders whose
+// timestamp cannot be parsed are counted as recent rather than silently dropped.
+func createdSince(order *OrderCart.Order, since time.Time) bool {
+	createdAt, err := time.Parse(time.RFC3339, order.CreatedAt)
+	return err != nil || !createdAt.Before(since)
+}
+
+// GetRestaurantMetrics summarizes the token's restaurant's orders over the configured
+// window: volume by status, cancellation rate and revenue. Results are cached per restaurant.
+func (oc *OrderCartController) GetRestaurantMetrics(c *gin.Context) {
+	restaurantId, _ := middleware.GetEntityID(c)
+	if restaurantId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "restaurantId is required"})
+		return
+	}
+
+	if cached, ok := oc.metricsCache.Get(restaurantId); ok {
+		c.Header("X-Cache", "HIT")
+		c.JSON(http.StatusOK, cached)
+		return
+	}
+
+	ctx, cancel := oc.backendContext(c)
+	defer cancel()
+
+	response, err := oc.restaurantOrders(ctx, &OrderCart.GetRestaurantOrdersRequest{RestaurantId: restaurantId})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	metrics := &model.FulfillmentMetrics{
+		RestaurantID:   restaurantId,
+		Window:         oc.metricsWindow.String(),
+		OrdersByStatus: make(map[string]int),
+	}
+	since := time.Now().Add(-oc.metricsWindow)
+	delivered := 0
+	for _, order := range response.Orders {
+		if !createdSince(order, since) {
+			continue
+		}
+		metrics.TotalOrders++
+		metrics.OrdersByStatus[order.OrderStatus]++
+		if order.OrderStatus == "DELIVERED" {
+			delivered++
+			metrics.DeliveredRevenue += order.TotalAmount
+		}
+	}
+	if metrics.TotalOrders > 0 {
+		metrics.CancellationRate = float64(metrics.OrdersByStatus["CANCELLED"]) / float64(metrics.TotalOrders)
+	}
+	if delivered > 0 {
+		metrics.AverageOrderValue = metrics.DeliveredRevenue / float64(delivered)
+	}
+
+	oc.metricsCache.Set(restaurantId, metrics)
+
+	c.Header("X-Cache", "MISS")
+	c.JSON(http.StatusOK, metrics)
+}
+
+// GetRestaurantOrderCounts counts the token's restaurant's orders per status for
+// dashboard badges. The order service has no aggregate, so the orders are fetched
+// once and grouped here, and the counts are cached briefly.
+func (oc *OrderCartController) GetRestaurantOrderCounts(c *gin.Context) {
+	restaurantId, _ := middleware.GetEntityID(c)
+	if restaurantId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "restaurantId is required"})
+		return
+	}
+
+	if cached, ok := oc.orderCountsCache.Get(restaurantId); ok {
+		c.Header("X-Cache", "HIT")
+		c.JSON(http.StatusOK, cached)
+		return
+	}
+
+	ctx, cancel := oc.backendContext(c)
+	defer cancel()
+
+	response, err := oc.restaurantOrders(ctx, &OrderCart.GetRestaurantOrdersRequest{
+		RestaurantId: restaurantId,
+		Status:       OrderStatusAll,
+	})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	counts := &model.OrderStatusCounts{
+		RestaurantID: restaurantId,
+		TotalOrders:  len(response.Orders),
+		Counts:       make(map[string]int, len(orderStatuses)),
+	}
+	for orderStatus := range orderStatuses {
+		counts.Counts[orderStatus] = 0
+	}
+	for _, order := range response.Orders {
+		counts.Counts[strings.ToUpper(order.OrderStatus)]++
+	}
+
+	oc.orderCountsCache.Set(restaurantId, counts)
+
+	c.Header("X-Cache", "MISS")
+	c.JSON(http.StatusOK, counts)
+}
+
+// GetRestaurantEarnings sums the token's restaurant's delivered orders between the
+// from and to dates (inclusive, YYYY-MM-DD in the operating hours timezone) and
+// deducts the platform commission. The range defaults to the last 30 days.
+func (oc *OrderCartController) GetRestaurantEarnings(c *gin.Context) {
+	restaurantId, _ := middleware.GetEntityID(c)
+	if restaurantId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "restaurantId is required"})
+		return
+	}
+
+	from, to, err := oc.parseEarningsRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := oc.backendContext(c)
+	defer cancel()
+
+	response, err := oc.restaurantOrders(ctx, &OrderCart.GetRestaurantOrdersRequest{
+		RestaurantId: restaurantId,
+		Status:       "DELIVERED",
+	})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	summary := &model.EarningsSummary{
+		RestaurantID:      restaurantId,
+		From:              from.Format(time.DateOnly),
+		To:                to.Format(time.DateOnly),
+		CommissionPercent: oc.commissionPercent,
+	}
+	dayIndex := make(map[string]int)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		dayIndex[date] = len(summary.Days)
+		summary.Days = append(summary.Days, model.DailyEarnings{Date: date})
+	}
+
+	for _, order := range response.Orders {
+		// The backend may ignore the status filter, so check it again
+		if order.OrderStatus != "DELIVERED" {
+			continue
+		}
+		// Orders without a parseable creation time cannot be placed in the range
+		createdAt, err := time.Parse(time.RFC3339, order.CreatedAt)
+		if err != nil {
+			continue
+		}
+		i, ok := dayIndex[createdAt.In(oc.schedule.location).Format(time.DateOnly)]
+		if !ok {
+			continue
+		}
+		day := &summary.Days[i]
+		day.OrderCount++
+		day.Gross += order.TotalAmount
+	}
+
+	for i := range summary.Days {
+		day := &summary.Days[i]
+		day.Commission = day.Gross * oc.commissionPercent / 100
+		day.NetEarnings = day.Gross - day.Commission
+
+		summary.OrderCount += day.OrderCount
+		summary.Gross += day.Gross
+		summary.Commission += day.Commission
+		summary.NetEarnings += day.NetEarnings
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// parseEarningsRange parses the earnings date range, defaulting to the 30 days
+// ending today, and rejects reversed or overly long ranges
+func (oc *OrderCartController) parseEarningsRange(fromQuery, toQuery string) (time.Time, time.Time, error) {
+	now := time.Now().In(oc.schedule.location)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, oc.schedule.location)
+	if toQuery != "" {
+		parsed, err := time.ParseInLocation(time.DateOnly, toQuery, oc.schedule.location)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("to must be a date in YYYY-MM-DD format")
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -29)
+	if fromQuery != "" {
+		parsed, err := time.ParseInLocation(time.DateOnly, fromQuery, oc.schedule.location)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("from must be a date in YYYY-MM-DD format")
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, errors.New("from must not be after to")
+	}
+	if oc.maxEarningsRangeDays > 0 && from.AddDate(0, 0, oc.maxEarningsRangeDays).Before(to.AddDate(0, 0, 1)) {
+		return time.Time{}, time.Time{}, fmt.Errorf("date range cannot exceed %d days", oc.maxEarningsRangeDays)
+	}
+	return from, to, nil
 }
 
 // func (oc *OrderCartController) UpdateOrderStatus(c *gin.Context) {
 // 	var req OrderCart.UpdateOrderStatusRequest
-// 	if err := c.BindJSON(&req); err != nil {
+// 	if err := c.BindJSON(req); err != nil {
 // 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 // 		return
 // 	}
@@ -394,10 +1917,10 @@ func (oc *OrderCartController) CancelOrder(c *gin.Context) {
 // 		return
 // 	}
 
-// 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// 	ctx, cancel := oc.backendContext(c)
 // 	defer cancel()
 
-// 	response, err := oc.orderCartClient.UpdateOrderStatus(ctx, &req)
+// 	response, err := oc.orderCartClient.UpdateOrderStatus(ctx, req)
 // 	if err != nil {
 // 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 // 		return
@@ -409,18 +1932,27 @@ func (oc *OrderCartController) CancelOrder(c *gin.Context) {
 func (oc *OrderCartController) GetRestaurantOrders(c *gin.Context) {
 	var req OrderCart.GetRestaurantOrdersRequest
 	req.RestaurantId, _ = middleware.GetEntityID(c)
-	// req.Status = c.Query("status")
 
 	if req.RestaurantId == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "restaurantId is required"})
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	status, ok := normalizeOrderStatus(c.Query("status"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order status"})
+		return
+	}
+	req.Status = status
+
+	ctx, cancel := oc.backendContext(c)
 	defer cancel()
 
-	response, err := oc.orderCartClient.GetRestaurantOrders(ctx, &req)
+	response, err := oc.restaurantOrders(ctx, &req)
 	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -428,10 +1960,66 @@ func (oc *OrderCartController) GetRestaurantOrders(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// GetPendingRestaurantOrders lists the token's restaurant's orders that still need
+// action, oldest first, with how long each has been waiting
+func (oc *OrderCartController) GetPendingRestaurantOrders(c *gin.Context) {
+	restaurantId, _ := middleware.GetEntityID(c)
+	if restaurantId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "restaurantId is required"})
+		return
+	}
+
+	ctx, cancel := oc.backendContext(c)
+	defer cancel()
+
+	response, err := oc.restaurantOrders(ctx, &OrderCart.GetRestaurantOrdersRequest{
+		RestaurantId: restaurantId,
+		Status:       OrderStatusAll,
+	})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	createdAt := make(map[*model.PendingOrder]time.Time)
+	queue := &model.PendingOrderQueue{
+		RestaurantID: restaurantId,
+		Orders:       []*model.PendingOrder{},
+	}
+	for _, order := range response.Orders {
+		if !actionableOrderStatuses[strings.ToUpper(order.OrderStatus)] {
+			continue
+		}
+		pending := &model.PendingOrder{Order: order}
+		if t, err := time.Parse(time.RFC3339, order.CreatedAt); err == nil {
+			waiting := int(now.Sub(t).Minutes())
+			pending.WaitingMinutes = &waiting
+			createdAt[pending] = t
+		}
+		queue.Orders = append(queue.Orders, pending)
+	}
+
+	// Oldest first; orders with an unparseable creation time go last
+	sort.SliceStable(queue.Orders, func(i, j int) bool {
+		ti, iok := createdAt[queue.Orders[i]]
+		tj, jok := createdAt[queue.Orders[j]]
+		if iok != jok {
+			return iok
+		}
+		return ti.Before(tj)
+	})
+	queue.Count = len(queue.Orders)
+
+	c.JSON(http.StatusOK, queue)
+}
+
 func (oc *OrderCartController) ConfirmOrder(c *gin.Context) {
-	var req OrderCart.ConfirmOrderRequest
-	if err := c.BindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	req, ok := bindJSON[OrderCart.ConfirmOrderRequest](c, oc.logger)
+	if !ok {
 		return
 	}
 	req.RestaurantId, _ = middleware.GetEntityID(c)
@@ -441,14 +2029,130 @@ func (oc *OrderCartController) ConfirmOrder(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := oc.backendContext(c)
 	defer cancel()
 
-	response, err := oc.orderCartClient.ConfirmOrder(ctx, &req)
+	response, err := oc.orderCartClient.ConfirmOrder(ctx, req)
 	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	oc.notifier.Notify(utils.WebhookEvent{
+		Event:        utils.EventOrderConfirmed,
+		RestaurantID: req.RestaurantId,
+		OrderID:      req.OrderId,
+		Status:       response.OrderStatus,
+	})
+
 	c.JSON(http.StatusOK, response)
 }
+
+// notifyOrderCancelled looks up the cancelled order's restaurant in the background
+// and emits the cancellation webhook to it
+func (oc *OrderCartController) notifyOrderCancelled(c *gin.Context, orderId, userId, reason string) {
+	if !oc.notifier.Enabled() {
+		return
+	}
+
+	// The request will have been answered by the time this call is made, so it must
+	// not be tied to it
+	ctx, cancel := middleware.DetachedContext(c, oc.timeout)
+	go func() {
+		defer cancel()
+
+		details, err := oc.orderCartClient.GetOrderDetailsByID(ctx, &OrderCart.GetOrderDetailsByIDRequest{
+			OrderId: orderId,
+			UserId:  userId,
+		})
+		if err != nil {
+			oc.logger.WithError(err).WithField("orderId", orderId).Error("Failed to look up cancelled order for webhook")
+			return
+		}
+
+		oc.notifier.Notify(utils.WebhookEvent{
+			Event:        utils.EventOrderCancelled,
+			RestaurantID: details.GetOrder().GetRestaurantId(),
+			OrderID:      orderId,
+			Status:       details.GetOrder().GetOrderStatus(),
+			Data:         gin.H{"reason": reason},
+		})
+	}()
+}
+
+// Data Export
+
+// ExportUserData assembles the user's profile, addresses, orders and carts into a
+// single downloadable JSON document
+func (oc *OrderCartController) ExportUserData(c *gin.Context) {
+	logger := middleware.RequestLogger(c, oc.logger)
+	userId, _ := middleware.GetEntityID(c)
+	if userId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "userId is required"})
+		return
+	}
+
+	ctx, cancel := oc.backendContext(c)
+	defer cancel()
+
+	var (
+		wg           sync.WaitGroup
+		profile      *User.GetProfileResponse
+		addresses    *User.GetAddressesResponse
+		orders       *OrderCart.GetOrderDetailsAllResponse
+		carts        *OrderCart.GetAllCartsResponse
+		profileErr   error
+		addressesErr error
+		ordersErr    error
+		cartsErr     error
+	)
+
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		profile, profileErr = oc.userClient.GetProfile(ctx, &User.GetProfileRequest{UserId: userId})
+	}()
+	go func() {
+		defer wg.Done()
+		addresses, addressesErr = oc.userClient.GetAddresses(ctx, &User.GetAddressesRequest{UserId: userId})
+	}()
+	go func() {
+		defer wg.Done()
+		orders, ordersErr = oc.userOrders(ctx, &OrderCart.GetOrderDetailsAllRequest{UserId: userId})
+	}()
+	go func() {
+		defer wg.Done()
+		carts, cartsErr = oc.orderCartClient.GetAllCarts(ctx, &OrderCart.GetAllCartsRequest{UserId: userId})
+	}()
+	wg.Wait()
+
+	for _, part := range []struct {
+		name string
+		err  error
+	}{
+		{"profile", profileErr},
+		{"addresses", addressesErr},
+		{"orders", ordersErr},
+		{"carts", cartsErr},
+	} {
+		if part.err != nil {
+			logger.WithError(part.err).WithField("userId", userId).Errorf("Failed to export user %s", part.name)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to export %s: %s", part.name, part.err.Error())})
+			return
+		}
+	}
+
+	exportedAt := time.Now().UTC()
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="foodbuddy-export-%s-%s.json"`, userId, exportedAt.Format("20060102")))
+	c.JSON(http.StatusOK, gin.H{
+		"userId":     userId,
+		"exportedAt": exportedAt.Format(time.RFC3339),
+		"profile":    profile,
+		"addresses":  addresses.GetAddresses(),
+		"orders":     orders.GetOrders(),
+		"carts":      carts.GetCarts(),
+	})
+}
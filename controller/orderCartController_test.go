@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	OrderCart "github.com/liju-github/CentralisedFoodbuddyMicroserviceProto/OrderCart"
+	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
+	"github.com/liju-github/FoodBuddyAPIGateway/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeOrderLister records the status filters of order list requests and returns one
+// order for the requested status. Like any backend not documented to accept it, it
+// rejects an empty status.
+type fakeOrderLister struct {
+	OrderCart.OrderCartServiceClient
+	mutex    sync.Mutex
+	statuses []string
+}
+
+func (f *fakeOrderLister) orders(orderStatus string) ([]*OrderCart.Order, error) {
+	f.mutex.Lock()
+	f.statuses = append(f.statuses, orderStatus)
+	f.mutex.Unlock()
+
+	if orderStatus == "" {
+		return nil, status.Error(codes.InvalidArgument, "status is required")
+	}
+	return []*OrderCart.Order{{OrderId: orderStatus, RestaurantId: "r1", RestaurantName: "Restaurant", OrderStatus: orderStatus}}, nil
+}
+
+func (f *fakeOrderLister) GetOrderDetailsAll(_ context.Context, req *OrderCart.GetOrderDetailsAllRequest, _ ...grpc.CallOption) (*OrderCart.GetOrderDetailsAllResponse, error) {
+	orders, err := f.orders(req.Status)
+	if err != nil {
+		return nil, err
+	}
+	return &OrderCart.GetOrderDetailsAllResponse{Orders: orders}, nil
+}
+
+func (f *fakeOrderLister) GetRestaurantOrders(_ context.Context, req *OrderCart.GetRestaurantOrdersRequest, _ ...grpc.CallOption) (*OrderCart.GetRestaurantOrdersResponse, error) {
+	orders, err := f.orders(req.Status)
+	if err != nil {
+		return nil, err
+	}
+	return &OrderCart.GetRestaurantOrdersResponse{Orders: orders}, nil
+}
+
+func TestOrderListStatusFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var allStatuses []string
+	for status := range orderStatuses {
+		allStatuses = append(allStatuses, status)
+	}
+	sort.Strings(allStatuses)
+
+	tests := []struct {
+		query        string
+		wantCode     int
+		wantStatuses []string
+	}{
+		{query: "", wantCode: http.StatusOK, wantStatuses: allStatuses},
+		{query: "?status=", wantCode: http.StatusOK, wantStatuses: allStatuses},
+		{query: "?status=all", wantCode: http.StatusOK, wantStatuses: allStatuses},
+		{query: "?status=pending", wantCode: http.StatusOK, wantStatuses: []string{"PENDING"}},
+		{query: "?status=REJECTED", wantCode: http.StatusBadRequest},
+	}
+
+	handlers := map[string]func(*OrderCartController) gin.HandlerFunc{
+		"GetOrderDetailsAll":  func(oc *OrderCartController) gin.HandlerFunc { return oc.GetOrderDetailsAll },
+		"GetRestaurantOrders": func(oc *OrderCartController) gin.HandlerFunc { return oc.GetRestaurantOrders },
+	}
+
+	for name, handler := range handlers {
+		for _, tt := range tests {
+			t.Run(name+tt.query, func(t *testing.T) {
+				backend := &fakeOrderLister{}
+				oc := &OrderCartController{
+					orderCartClient:     backend,
+					restaurantNameCache: utils.NoopCache{},
+					timeout:             time.Second,
+				}
+
+				router := gin.New()
+				router.GET("/orders", func(c *gin.Context) {
+					c.Set(middleware.EntityID, "entity1")
+					c.Next()
+				}, handler(oc))
+
+				recorder := httptest.NewRecorder()
+				router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/orders"+tt.query, nil))
+
+				if recorder.Code != tt.wantCode {
+					t.Fatalf("got %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+				}
+				if tt.wantCode != http.StatusOK {
+					if len(backend.statuses) != 0 {
+						t.Errorf("backend called for an invalid status")
+					}
+					return
+				}
+				sort.Strings(backend.statuses)
+				if !slices.Equal(backend.statuses, tt.wantStatuses) {
+					t.Errorf("backend status filters %v, want %v", backend.statuses, tt.wantStatuses)
+				}
+
+				var body struct {
+					Orders []json.RawMessage `json:"orders"`
+				}
+				if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+					t.Fatalf("decode response: %v", err)
+				}
+				if len(body.Orders) != len(tt.wantStatuses) {
+					t.Errorf("got %d orders, want %d", len(body.Orders), len(tt.wantStatuses))
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaginationClampedHeader tells the client its limit was lowered to the maximum
+const PaginationClampedHeader = "X-Pagination-Clamped"
+
+// parseLimit reads the limit query parameter, defaulting to def. A limit above maxLimit
+// is lowered to maxLimit rather than rejected, and PaginationClampedHeader is set so the
+// client knows it got fewer items than it asked for. It answers 400 and returns
+// false for a limit that is not a positive integer.
+func parseLimit(c *gin.Context, def, maxLimit int) (int, bool) {
+	limitParam := c.Query("limit")
+	if limitParam == "" {
+		return min(def, maxLimit), true
+	}
+
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return 0, false
+	}
+	if limit > maxLimit {
+		c.Header(PaginationClampedHeader, "true")
+		return maxLimit, true
+	}
+	return limit, true
+}
@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"sync"
+
+	"github.com/liju-github/FoodBuddyAPIGateway/model"
+)
+
+// failureCollector records the backend lookups of an aggregate response that
+// failed. It is safe for concurrent use by fan-out workers.
+type failureCollector struct {
+	mu       sync.Mutex
+	failures []model.FetchFailure
+}
+
+func (f *failureCollector) add(source string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = append(f.failures, model.FetchFailure{Source: source, Error: err.Error()})
+}
+
+// result returns the PartialResult to embed in the response
+func (f *failureCollector) result() model.PartialResult {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return model.PartialResult{Partial: len(f.failures) > 0, Failures: f.failures}
+}
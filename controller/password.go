@@ -0,0 +1,17 @@
+package controller
+
+import (
+	config "github.com/liju-github/FoodBuddyAPIGateway/configs"
+	"github.com/liju-github/FoodBuddyAPIGateway/utils"
+)
+
+// passwordPolicy builds the password complexity rules from the configuration
+func passwordPolicy(cfg config.Config) utils.PasswordPolicy {
+	return utils.PasswordPolicy{
+		MinLength:        cfg.PasswordMinLength,
+		MaxLength:        cfg.PasswordMaxLength,
+		RequireUppercase: cfg.PasswordRequireUppercase,
+		RequireDigit:     cfg.PasswordRequireDigit,
+		RequireSpecial:   cfg.PasswordRequireSpecial,
+	}
+}
@@ -2,11 +2,14 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -14,45 +17,68 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"github.com/golang-jwt/jwt/v5"
 	restaurantPb "github.com/liju-github/CentralisedFoodbuddyMicroserviceProto/Restaurant"
 	config "github.com/liju-github/FoodBuddyAPIGateway/configs"
 	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
 	"github.com/liju-github/FoodBuddyAPIGateway/model"
+	"github.com/liju-github/FoodBuddyAPIGateway/utils"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type RestaurantController struct {
 	restaurantClient restaurantPb.RestaurantServiceClient
 	validator        *validator.Validate
 	logger           *logrus.Logger
-	jwtSecret        []byte
+	sessions         sessionTokens
+	listingCache     utils.Cache
+	valuationCache   utils.Cache
+	catalogVersion   *utils.CatalogVersion
+	timeout          time.Duration
+
+	lowStockThreshold int32
+	maxProductPrice   float64
+	passwordPolicy    utils.PasswordPolicy
+	fanOutLimit       int
+	schedule          operatingSchedule
+
+	// stockLocks serializes stock changes per product and stockVersions counts them,
+	// see checkStockVersion
+	stockLocks    *middleware.KeyedMutex
+	stockVersions *utils.StockVersions
+
+	blockedEmailDomains utils.EmailDomainDenyList
+	cookies             authCookies
 }
 
 // Custom validation rules
 var (
-	emailRegex    = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-	passwordRegex = regexp.MustCompile(`^[a-zA-Z0-9!@#$%^&*]{8,}$`)
-	nameRegex     = regexp.MustCompile(`^[a-zA-Z\s]{2,50}$`)
-	phoneRegex    = regexp.MustCompile(`^\d{10}$`)
-	pincodeRegex  = regexp.MustCompile(`^\d{6}$`)
+	emailRegex   = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+	nameRegex    = regexp.MustCompile(`^[a-zA-Z\s]{2,50}$`)
+	pincodeRegex = regexp.MustCompile(`^\d{6}$`)
 )
 
+// listingCacheParams are the query parameters the public listing depends on, and
+// the only ones its cache key is built from. The backend takes no pagination or
+// filters yet, so every listing request shares one entry; add them here when it does.
+var listingCacheParams []string
+
 // Validation functions
 func (rc *RestaurantController) validateEmail(email string) bool {
 	return emailRegex.MatchString(email)
 }
 
-func (rc *RestaurantController) validatePassword(password string) bool {
-	return passwordRegex.MatchString(password)
+func (rc *RestaurantController) validatePassword(password string) error {
+	return rc.passwordPolicy.Validate(password)
 }
 
 func (rc *RestaurantController) validateName(name string) bool {
 	return nameRegex.MatchString(name)
 }
 
-func (rc *RestaurantController) validatePhone(phone uint64) bool {
-	return phoneRegex.MatchString(fmt.Sprint(phone))
+func (rc *RestaurantController) validatePhone(phone model.PhoneNumber) bool {
+	return phone.Valid()
 }
 
 func (rc *RestaurantController) validatePincode(pincode string) bool {
@@ -75,13 +101,41 @@ func (rc *RestaurantController) validateAddress(address model.Address) error {
 	return nil
 }
 
+// toModelAddress converts a restaurant service address into the gateway model.
+// A nil address converts to an empty one so validation reports the missing fields.
+func toModelAddress(address *restaurantPb.Address) model.Address {
+	if address == nil {
+		return model.Address{}
+	}
+	return model.Address{
+		StreetName: address.StreetName,
+		Locality:   address.Locality,
+		State:      address.State,
+		Pincode:    address.Pincode,
+	}
+}
+
+// toRestaurantAddress converts a gateway address into the restaurant service format
+func toRestaurantAddress(address model.Address) *restaurantPb.Address {
+	return &restaurantPb.Address{
+		StreetName: address.StreetName,
+		Locality:   address.Locality,
+		State:      address.State,
+		Pincode:    address.Pincode,
+	}
+}
+
 func (rc *RestaurantController) validateRestaurantInput(request model.RestaurantSignupRequest) error {
 	if !rc.validateEmail(request.OwnerEmail) {
 		return fmt.Errorf("invalid email format")
 	}
 
-	if !rc.validatePassword(request.Password) {
-		return fmt.Errorf("password must be at least 8 characters")
+	if rc.blockedEmailDomains.Blocked(request.OwnerEmail) {
+		return fmt.Errorf("email domain not allowed")
+	}
+
+	if err := rc.validatePassword(request.Password); err != nil {
+		return err
 	}
 
 	if !rc.validateName(request.RestaurantName) {
@@ -99,7 +153,7 @@ func (rc *RestaurantController) validateRestaurantInput(request model.Restaurant
 	return nil
 }
 
-func NewRestaurantController(restaurantClient restaurantPb.RestaurantServiceClient) *RestaurantController {
+func NewRestaurantController(cfg config.Config, restaurantClient restaurantPb.RestaurantServiceClient, tokens *middleware.TokenService, blockedEmailDomains utils.EmailDomainDenyList, listingCache, valuationCache utils.Cache, catalogVersion *utils.CatalogVersion) *RestaurantController {
 	validate := validator.New()
 	logger := logrus.New()
 
@@ -137,54 +191,110 @@ func NewRestaurantController(restaurantClient restaurantPb.RestaurantServiceClie
 	logger = logger.WithFields(logrus.Fields{
 		"service": "api_gateway",
 		"version": "1.0",
-		"env":     config.LoadConfig().Environment,
+		"env":     cfg.Environment,
 	}).Logger
 
-	// Get JWT secret from environment variable
-	jwtSecret := []byte(config.LoadConfig().JWTSecretKey)
-
 	return &RestaurantController{
 		restaurantClient: restaurantClient,
 		validator:        validate,
 		logger:           logger,
-		jwtSecret:        jwtSecret,
+		sessions:         newSessionTokens(middleware.RoleRestaurant, tokens, cfg, logger),
+		listingCache:     listingCache,
+		valuationCache:   valuationCache,
+		catalogVersion:   catalogVersion,
+		timeout:          cfg.RestaurantTimeout,
+
+		lowStockThreshold: int32(cfg.LowStockThreshold),
+		maxProductPrice:   cfg.MaxProductPrice,
+		passwordPolicy:    passwordPolicy(cfg),
+		fanOutLimit:       cfg.MaxBackendConcurrency,
+		schedule:          loadOperatingHours(cfg, logger),
+
+		stockLocks:    middleware.NewKeyedMutex(),
+		stockVersions: utils.NewStockVersions(),
+
+		blockedEmailDomains: blockedEmailDomains,
+		cookies:             newAuthCookies(cfg),
 	}
 }
 
-func (rc *RestaurantController) generateToken(ID string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":      ID,
-		"role":    middleware.RoleRestaurant,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
-		"created": time.Now().Unix(),
-	})
+// invalidateProductCaches drops cached responses built from product data after a
+// change and bumps the catalog version
+func (rc *RestaurantController) invalidateProductCaches() {
+	rc.listingCache.Invalidate()
+	rc.valuationCache.Invalidate()
+	rc.catalogVersion.Bump()
+}
+
+// GetCatalogVersion reports the product catalog's version and when it last changed,
+// so clients can decide whether to refetch it. The response carries an ETag and
+// Last-Modified, and a matching If-None-Match gets 304.
+func (rc *RestaurantController) GetCatalogVersion(c *gin.Context) {
+	version, modifiedAt := rc.catalogVersion.Get()
+	c.Header("Last-Modified", modifiedAt.UTC().Format(http.TimeFormat))
+	if err := utils.JSONWithETag(c, http.StatusOK, model.CatalogVersion{
+		Version:      version,
+		LastModified: modifiedAt.UTC().Format(time.RFC3339Nano),
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// backendContext returns a context for a backend call made on behalf of c, bounded by
+// the Restaurant service timeout and cancelled if the client disconnects
+func (rc *RestaurantController) backendContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	return middleware.WithRequestTimeout(c, rc.timeout)
+}
+
+// RefreshToken exchanges a restaurant refresh token for a new access and refresh token
+func (rc *RestaurantController) RefreshToken(c *gin.Context) {
+	rc.sessions.refresh(c)
+}
+
+// validateProduct applies the product rules shared by additions, edits and menu imports
+func (rc *RestaurantController) validateProduct(name string, price float64, stock int32) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return errors.New("Product name is required")
+	case price <= 0:
+		return errors.New("Price must be greater than 0")
+	case rc.maxProductPrice > 0 && price > rc.maxProductPrice:
+		return fmt.Errorf("Price cannot exceed %g", rc.maxProductPrice)
+	case stock < 0:
+		return errors.New("Stock cannot be negative")
+	}
+	return nil
+}
 
-	return token.SignedString(rc.jwtSecret)
+// hasProductNamed reports whether any product has the name, ignoring case and surrounding whitespace
+func hasProductNamed(products []*restaurantPb.Product, name string) bool {
+	name = strings.TrimSpace(name)
+	for _, product := range products {
+		if strings.EqualFold(strings.TrimSpace(product.Name), name) {
+			return true
+		}
+	}
+	return false
 }
 
 // RestaurantSignup handles restaurant registration
 func (rc *RestaurantController) RestaurantSignup(ctx *gin.Context) {
-	var request model.RestaurantSignupRequest
-
-	if err := ctx.ShouldBindJSON(&request); err != nil {
-		rc.logger.WithFields(logrus.Fields{
-			"error": err.Error(),
-			"path":  "/auth/restaurant/signup",
-		}).Error("Failed to bind signup request")
-		ctx.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrInvalidRequestFormat, err))
+	logger := middleware.RequestLogger(ctx, rc.logger)
+	request, ok := bindJSON[model.RestaurantSignupRequest](ctx, rc.logger)
+	if !ok {
 		return
 	}
 
 	// Log sanitized request (excluding password)
-	rc.logger.WithFields(logrus.Fields{
+	logger.WithFields(logrus.Fields{
 		"restaurantName": request.RestaurantName,
 		"ownerEmail":     request.OwnerEmail,
 		"path":           "/auth/restaurant/signup",
 	}).Info("Processing signup request")
 
 	// Validate input
-	if err := rc.validateRestaurantInput(request); err != nil {
-		rc.logger.WithFields(logrus.Fields{
+	if err := rc.validateRestaurantInput(*request); err != nil {
+		logger.WithFields(logrus.Fields{
 			"error": err.Error(),
 			"path":  "/auth/restaurant/signup",
 		}).Warn("Validation failed")
@@ -192,23 +302,30 @@ func (rc *RestaurantController) RestaurantSignup(ctx *gin.Context) {
 		return
 	}
 
+	phoneNumber, err := request.PhoneNumber.Uint64()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse(err.Error(), nil))
+		return
+	}
+
 	// Convert to protobuf request
 	pbRequest := &restaurantPb.RestaurantSignupRequest{
 		RestaurantName: request.RestaurantName,
 		OwnerEmail:     request.OwnerEmail,
 		Password:       request.Password,
-		PhoneNumber:    request.PhoneNumber,
-		Address: &restaurantPb.Address{
-			StreetName: request.Address.StreetName,
-			Locality:   request.Address.Locality,
-			State:      request.Address.State,
-			Pincode:    request.Address.Pincode,
-		},
+		PhoneNumber:    phoneNumber,
+		Address:        toRestaurantAddress(request.Address),
 	}
 
-	response, err := rc.restaurantClient.RestaurantSignup(context.Background(), pbRequest)
+	grpcCtx, cancel := rc.backendContext(ctx)
+	defer cancel()
+
+	response, err := rc.restaurantClient.RestaurantSignup(grpcCtx, pbRequest)
 	if err != nil {
-		rc.logger.WithFields(logrus.Fields{
+		if abortIfUnanswered(ctx, err) {
+			return
+		}
+		logger.WithFields(logrus.Fields{
 			"error": err.Error(),
 			"path":  "/auth/restaurant/signup",
 		}).Error("Signup failed")
@@ -216,10 +333,10 @@ func (rc *RestaurantController) RestaurantSignup(ctx *gin.Context) {
 		return
 	}
 
-	// Generate JWT token
-	token, err := rc.generateToken(response.RestaurantId)
+	// Generate JWT token, a fresh login starts a new session
+	tokens, err := rc.sessions.issue(response.RestaurantId, time.Now().Unix(), logger)
 	if err != nil {
-		rc.logger.WithFields(logrus.Fields{
+		logger.WithFields(logrus.Fields{
 			"restaurantId": response.RestaurantId,
 			"error":        err.Error(),
 		}).Error(model.ErrFailedGenerateToken)
@@ -227,38 +344,38 @@ func (rc *RestaurantController) RestaurantSignup(ctx *gin.Context) {
 		return
 	}
 
-	response.Token = token
+	response.Token = tokens.Token
+	rc.cookies.set(ctx, response.Token)
 
-	rc.logger.WithFields(logrus.Fields{
+	logger.WithFields(logrus.Fields{
 		"restaurantId":   response.RestaurantId,
 		"restaurantName": request.RestaurantName,
 	}).Info("Signup successful")
 
-	ctx.JSON(http.StatusOK, model.SuccessResponse("Restaurant registered successfully", response))
+	ctx.JSON(http.StatusOK, model.SuccessResponse("Restaurant registered successfully", struct {
+		*restaurantPb.RestaurantSignupResponse
+		RefreshToken string `json:"refreshToken"`
+		ExpiresIn    int64  `json:"expiresIn"`
+	}{response, tokens.RefreshToken, tokens.ExpiresIn}))
 }
 
 // RestaurantLogin handles restaurant authentication
 func (rc *RestaurantController) RestaurantLogin(ctx *gin.Context) {
-	var request model.RestaurantLoginRequest
-
-	if err := ctx.ShouldBindJSON(&request); err != nil {
-		rc.logger.WithFields(logrus.Fields{
-			"error": err.Error(),
-			"path":  "/auth/restaurant/login",
-		}).Error("Failed to bind login request")
-		ctx.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrInvalidRequestFormat, err))
+	logger := middleware.RequestLogger(ctx, rc.logger)
+	request, ok := bindJSON[model.RestaurantLoginRequest](ctx, rc.logger)
+	if !ok {
 		return
 	}
 
 	// Log sanitized request (excluding password)
-	rc.logger.WithFields(logrus.Fields{
+	logger.WithFields(logrus.Fields{
 		"ownerEmail": request.OwnerEmail,
 		"path":       "/auth/restaurant/login",
 	}).Info("Processing login request")
 
 	// Validate input
 	if !rc.validateEmail(request.OwnerEmail) {
-		rc.logger.WithFields(logrus.Fields{
+		logger.WithFields(logrus.Fields{
 			"email": request.OwnerEmail,
 			"path":  "/auth/restaurant/login",
 		}).Warn("Invalid email format")
@@ -266,9 +383,11 @@ func (rc *RestaurantController) RestaurantLogin(ctx *gin.Context) {
 		return
 	}
 
-	if !rc.validatePassword(request.Password) {
-		rc.logger.WithField("email", request.OwnerEmail).Warn("Invalid password format")
-		ctx.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrPasswordTooShort, nil))
+	// Only the length bounds apply at login, so tightening the complexity rules
+	// does not lock out restaurants registered before the change
+	if err := rc.passwordPolicy.ValidateLength(request.Password); err != nil {
+		logger.WithField("email", request.OwnerEmail).Warn("Invalid password format")
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse(err.Error(), nil))
 		return
 	}
 
@@ -278,9 +397,15 @@ func (rc *RestaurantController) RestaurantLogin(ctx *gin.Context) {
 		Password:   request.Password,
 	}
 
-	response, err := rc.restaurantClient.RestaurantLogin(context.Background(), pbRequest)
+	grpcCtx, cancel := rc.backendContext(ctx)
+	defer cancel()
+
+	response, err := rc.restaurantClient.RestaurantLogin(grpcCtx, pbRequest)
 	if err != nil {
-		rc.logger.WithFields(logrus.Fields{
+		if abortIfUnanswered(ctx, err) {
+			return
+		}
+		logger.WithFields(logrus.Fields{
 			"error": err.Error(),
 			"path":  "/auth/restaurant/login",
 		}).Error("Login failed")
@@ -288,10 +413,10 @@ func (rc *RestaurantController) RestaurantLogin(ctx *gin.Context) {
 		return
 	}
 
-	// Generate JWT token
-	token, err := rc.generateToken(response.RestaurantId)
+	// Generate JWT token, a fresh login starts a new session
+	tokens, err := rc.sessions.issue(response.RestaurantId, time.Now().Unix(), logger)
 	if err != nil {
-		rc.logger.WithFields(logrus.Fields{
+		logger.WithFields(logrus.Fields{
 			"restaurantId": response.RestaurantId,
 			"error":        err.Error(),
 		}).Error(model.ErrFailedGenerateToken)
@@ -299,29 +424,33 @@ func (rc *RestaurantController) RestaurantLogin(ctx *gin.Context) {
 		return
 	}
 
-	response.Token = token
+	response.Token = tokens.Token
+	rc.cookies.set(ctx, response.Token)
 
-	rc.logger.WithFields(logrus.Fields{
+	logger.WithFields(logrus.Fields{
 		"restaurantId": response.RestaurantId,
 		"ownerEmail":   request.OwnerEmail,
 	}).Info("Login successful")
 
-	ctx.JSON(http.StatusOK, model.SuccessResponse("Login successful", response))
+	ctx.JSON(http.StatusOK, model.SuccessResponse("Login successful", struct {
+		*restaurantPb.RestaurantLoginResponse
+		RefreshToken string `json:"refreshToken"`
+		ExpiresIn    int64  `json:"expiresIn"`
+	}{response, tokens.RefreshToken, tokens.ExpiresIn}))
 }
 
 func (rc *RestaurantController) EditRestaurant(c *gin.Context) {
+	logger := middleware.RequestLogger(c, rc.logger)
 	// Get restaurant ID from JWT token
 	restaurantID, exists := middleware.GetEntityID(c)
 	if !exists {
-		rc.logger.Error("Restaurant ID not found in token")
+		logger.Error("Restaurant ID not found in token")
 		c.JSON(http.StatusUnauthorized, model.ErrorResponse("Restaurant ID not found in token", nil))
 		return
 	}
 
-	var request restaurantPb.EditRestaurantRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		rc.logger.WithError(err).Error("Failed to bind edit restaurant request")
-		c.JSON(http.StatusBadRequest, model.ErrorResponse("Invalid request format", err))
+	request, ok := bindJSON[restaurantPb.EditRestaurantRequest](c, rc.logger)
+	if !ok {
 		return
 	}
 
@@ -330,46 +459,57 @@ func (rc *RestaurantController) EditRestaurant(c *gin.Context) {
 
 	// Validate input
 	if !rc.validateName(request.RestaurantName) {
-		rc.logger.Error("Invalid restaurant name format")
+		logger.Error("Invalid restaurant name format")
 		c.JSON(http.StatusBadRequest, model.ErrorResponse("Invalid restaurant name format", nil))
 		return
 	}
 
-	if !rc.validatePhone(request.PhoneNumber) {
-		rc.logger.Error("Invalid phone number format")
+	if !rc.validatePhone(model.PhoneNumberFromUint64(request.PhoneNumber)) {
+		logger.Error("Invalid phone number format")
 		c.JSON(http.StatusBadRequest, model.ErrorResponse("Invalid phone number format", nil))
 		return
 	}
 
-	if err := rc.validateAddress(model.Address{
-		StreetName: request.Address.StreetName,
-		Locality:   request.Address.Locality,
-		State:      request.Address.State,
-		Pincode:    request.Address.Pincode,
-	}); err != nil {
-		rc.logger.WithError(err).Error("Invalid address")
+	if err := rc.validateAddress(toModelAddress(request.Address)); err != nil {
+		logger.WithError(err).Error("Invalid address")
 		c.JSON(http.StatusBadRequest, model.ErrorResponse("Invalid address", err))
 		return
 	}
-	response, err := rc.restaurantClient.EditRestaurant(context.Background(), &request)
+
+	ctx, cancel := rc.backendContext(c)
+	defer cancel()
+
+	response, err := rc.restaurantClient.EditRestaurant(ctx, request)
 	if err != nil {
-		rc.logger.WithError(err).Error("Failed to edit restaurant")
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithError(err).Error("Failed to edit restaurant")
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse("Failed to edit restaurant", err))
 		return
 	}
 
+	rc.invalidateProductCaches()
+
 	c.JSON(http.StatusOK, model.SuccessResponse("Restaurant updated successfully", response))
 }
 
 func (rc *RestaurantController) GetRestaurantProductsByID(c *gin.Context) {
+	logger := middleware.RequestLogger(c, rc.logger)
 	restaurantID := c.Query("restaurantId")
 	request := &restaurantPb.GetRestaurantProductsByIDRequest{
 		RestaurantId: restaurantID,
 	}
 
-	response, err := rc.restaurantClient.GetRestaurantProductsByID(context.Background(), request)
+	ctx, cancel := rc.backendContext(c)
+	defer cancel()
+
+	response, err := rc.restaurantClient.GetRestaurantProductsByID(ctx, request)
 	if err != nil {
-		rc.logger.WithError(err).Error("Failed to get restaurant products")
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithError(err).Error("Failed to get restaurant products")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -378,25 +518,70 @@ func (rc *RestaurantController) GetRestaurantProductsByID(c *gin.Context) {
 }
 
 func (rc *RestaurantController) GetAllRestaurantWithProducts(c *gin.Context) {
-	request := &restaurantPb.GetAllRestaurantAndProductsRequest{}
+	logger := middleware.RequestLogger(c, rc.logger)
+	fields := utils.ParseFields(c)
 
-	response, err := rc.restaurantClient.GetAllRestaurantWithProducts(context.Background(), request)
-	if err != nil {
-		rc.logger.WithError(err).Error("Failed to get all restaurants with products")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	// Cache entries are keyed by the listing parameters only, so other query parameters
+	// cannot create entries. Field selection is applied after the cache.
+	cacheKey := utils.CacheKey(c.Request.URL.Query(), listingCacheParams...)
+
+	cacheStatus := "HIT"
+	cached, ok := rc.listingCache.Get(cacheKey)
+	if !ok {
+		request := &restaurantPb.GetAllRestaurantAndProductsRequest{}
+
+		ctx, cancel := rc.backendContext(c)
+		defer cancel()
+
+		response, err := rc.restaurantClient.GetAllRestaurantWithProducts(ctx, request)
+		if err != nil {
+			if abortIfUnanswered(c, err) {
+				return
+			}
+			logger.WithError(err).Error("Failed to get all restaurants with products")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		rc.listingCache.Set(cacheKey, response)
+		cached, cacheStatus = response, "MISS"
 	}
+	c.Header("X-Cache", cacheStatus)
 
-	c.JSON(http.StatusOK, response)
+	response := cached.(*restaurantPb.GetAllRestaurantWithProductsResponse)
+	var body interface{} = response
+	if len(fields) > 0 {
+		// Field selection applies to each restaurant in the listing
+		restaurants, err := utils.ProjectFields(response.Restaurants, fields)
+		if err != nil {
+			logger.WithError(err).Error("Failed to project restaurant fields")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		body = gin.H{
+			"restaurants": restaurants,
+			"message":     response.Message,
+		}
+	}
+
+	// The ETag is computed from the rendered body, so a projected listing gets its own
+	// ETag and clients polling the listing get a 304 while it is unchanged
+	if err := utils.JSONWithETag(c, http.StatusOK, body); err != nil {
+		logger.WithError(err).Error("Failed to encode restaurant listing")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
 
 func (rc *RestaurantController) GetAllProducts(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := rc.backendContext(c)
 	defer cancel()
 
 	// Call the gRPC service
 	response, err := rc.restaurantClient.GetAllProducts(ctx, &restaurantPb.GetAllProductsRequest{})
 	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -410,183 +595,239 @@ func (rc *RestaurantController) GetAllProducts(c *gin.Context) {
 }
 
 func (rc *RestaurantController) AddProduct(c *gin.Context) {
-	var request restaurantPb.AddProductRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		rc.logger.WithError(err).Error("Failed to bind add product request")
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	logger := middleware.RequestLogger(c, rc.logger)
+	request, ok := bindJSON[restaurantPb.AddProductRequest](c, rc.logger)
+	if !ok {
 		return
 	}
 
 	// Get restaurant ID from token
 	restaurantID, exists := middleware.GetEntityID(c)
 	if !exists {
-		rc.logger.Error("Restaurant ID not found in token")
+		logger.Error("Restaurant ID not found in token")
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
 	request.RestaurantId = restaurantID
 
-	response, err := rc.restaurantClient.AddProduct(context.Background(), &request)
+	if err := rc.validateProduct(request.Name, request.Price, request.Stock); err != nil {
+		logger.WithError(err).Error("Invalid product")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := rc.backendContext(c)
+	defer cancel()
+
+	// The restaurant service accepts duplicate names, so they are rejected here
+	productsResp, err := rc.restaurantClient.GetRestaurantProductsByID(ctx, &restaurantPb.GetRestaurantProductsByIDRequest{
+		RestaurantId: restaurantID,
+	})
 	if err != nil {
-		rc.logger.WithError(err).Error("Failed to add product")
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithError(err).Error("Failed to get products for duplicate name check")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if hasProductNamed(productsResp.Products, request.Name) {
+		logger.WithField("name", request.Name).Warn("Duplicate product name")
+		c.JSON(http.StatusConflict, gin.H{
+			"error": model.ErrDuplicateProductName,
+			"code":  model.CodeDuplicateProductName,
+		})
+		return
+	}
 
-	c.JSON(http.StatusOK, response)
-}
-
-func (rc *RestaurantController) EditProduct(c *gin.Context) {
-	var request restaurantPb.EditProductRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		rc.logger.WithError(err).Error("Failed to bind edit product request")
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	response, err := rc.restaurantClient.AddProduct(ctx, request)
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithError(err).Error("Failed to add product")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Get restaurant ID and role from token
-	role, exists := middleware.GetEntityRole(c)
+	rc.invalidateProductCaches()
+
+	if response.ProductId != "" {
+		c.Header("Location", "/api/public/restaurants/products/details?productId="+url.QueryEscape(response.ProductId))
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// authorizeProductOwner checks that the product belongs to the restaurant in the
+// token, responding with an error and returning false when it does not
+func (rc *RestaurantController) authorizeProductOwner(c *gin.Context, logger *logrus.Entry, productID, action string) (string, bool) {
+	restaurantID, exists := middleware.GetEntityID(c)
 	if !exists {
-		rc.logger.Error("Role not found in token")
+		logger.Error("Restaurant ID not found in token")
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
+		return "", false
 	}
 
-	// If not admin, verify restaurant ownership
-	if role != middleware.RoleAdmin {
-		restaurantID, exists := middleware.GetEntityID(c)
-		if !exists {
-			rc.logger.Error("Restaurant ID not found in token")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			return
-		}
+	if strings.TrimSpace(productID) == "" {
+		logger.Error("Product ID is required")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Product ID is required"})
+		return "", false
+	}
 
-		// Get restaurant ID for the product
-		productRestaurantResp, err := rc.restaurantClient.GetRestaurantIDviaProductID(context.Background(), &restaurantPb.GetRestaurantIDviaProductIDRequest{
-			ProductId: request.ProductId,
-		})
-		if err != nil {
-			rc.logger.WithError(err).Error("Failed to get restaurant ID for product")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+	ctx, cancel := rc.backendContext(c)
+	defer cancel()
 
-		// Verify ownership
-		if productRestaurantResp.RestaurantId != restaurantID {
-			rc.logger.Error("Restaurant not authorized to edit this product")
-			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to edit this product"})
-			return
+	productRestaurantResp, err := rc.restaurantClient.GetRestaurantIDviaProductID(ctx, &restaurantPb.GetRestaurantIDviaProductIDRequest{
+		ProductId: productID,
+	})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return "", false
 		}
+		logger.WithError(err).Error("Failed to get restaurant ID for product")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return "", false
+	}
 
-		request.RestaurantId = restaurantID
+	if productRestaurantResp.RestaurantId != restaurantID {
+		logger.Errorf("Restaurant not authorized to %s this product", action)
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Not authorized to %s this product", action)})
+		return "", false
 	}
+	return restaurantID, true
+}
 
-	// Validate product details
-	if strings.TrimSpace(request.ProductId) == "" {
-		rc.logger.Error("Product ID is required")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Product ID is required"})
+// EditProduct lets a restaurant edit one of its own products
+func (rc *RestaurantController) EditProduct(c *gin.Context) {
+	logger := middleware.RequestLogger(c, rc.logger)
+	request, ok := bindJSON[restaurantPb.EditProductRequest](c, rc.logger)
+	if !ok {
 		return
 	}
 
-	if strings.TrimSpace(request.Name) == "" {
-		rc.logger.Error("Product name is required")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Product name is required"})
+	restaurantID, ok := rc.authorizeProductOwner(c, logger, request.ProductId, "edit")
+	if !ok {
+		return
+	}
+	request.RestaurantId = restaurantID
+
+	rc.editProduct(c, logger, request)
+}
+
+// AdminEditProduct lets an admin edit any restaurant's product
+func (rc *RestaurantController) AdminEditProduct(c *gin.Context) {
+	request, ok := bindJSON[restaurantPb.EditProductRequest](c, rc.logger)
+	if !ok {
 		return
 	}
+	rc.editProduct(c, middleware.RequestLogger(c, rc.logger), request)
+}
 
-	if request.Price <= 0 {
-		rc.logger.Error("Invalid product price")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Price must be greater than 0"})
+func (rc *RestaurantController) editProduct(c *gin.Context, logger *logrus.Entry, request *restaurantPb.EditProductRequest) {
+	// Validate product details
+	if strings.TrimSpace(request.ProductId) == "" {
+		logger.Error("Product ID is required")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Product ID is required"})
 		return
 	}
 
-	if request.Stock < 0 {
-		rc.logger.Error("Invalid product stock")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Stock cannot be negative"})
+	if err := rc.validateProduct(request.Name, request.Price, request.Stock); err != nil {
+		logger.WithError(err).Error("Invalid product")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	response, err := rc.restaurantClient.EditProduct(context.Background(), &request)
+	ctx, cancel := rc.backendContext(c)
+	defer cancel()
+
+	// Edits set the stock too, so they are stock changes as well
+	unlock := rc.stockLocks.Lock(request.ProductId)
+	defer unlock()
+
+	response, err := rc.restaurantClient.EditProduct(ctx, request)
 	if err != nil {
-		rc.logger.WithError(err).Error("Failed to edit product")
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithError(err).Error("Failed to edit product")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	rc.stockVersions.Bump(request.ProductId)
+
+	rc.invalidateProductCaches()
 
 	c.JSON(http.StatusOK, response)
 }
 
+// DeleteProductByID lets a restaurant delete one of its own products
 func (rc *RestaurantController) DeleteProductByID(c *gin.Context) {
-	var request restaurantPb.DeleteProductByIDRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		rc.logger.WithError(err).Error("Failed to bind delete product request")
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	logger := middleware.RequestLogger(c, rc.logger)
+	request, ok := bindJSON[restaurantPb.DeleteProductByIDRequest](c, rc.logger)
+	if !ok {
 		return
 	}
 
-	// Get role from token
-	role, exists := middleware.GetEntityRole(c)
-	if !exists {
-		rc.logger.Error("Role not found in token")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	restaurantID, ok := rc.authorizeProductOwner(c, logger, request.ProductId, "delete")
+	if !ok {
 		return
 	}
+	request.RestaurantId = restaurantID
 
-	// If not admin, verify restaurant ownership
-	if role != middleware.RoleAdmin {
-		restaurantID, exists := middleware.GetEntityID(c)
-		if !exists {
-			rc.logger.Error("Restaurant ID not found in token")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			return
-		}
-
-		// Get restaurant ID for the product
-		productRestaurantResp, err := rc.restaurantClient.GetRestaurantIDviaProductID(context.Background(), &restaurantPb.GetRestaurantIDviaProductIDRequest{
-			ProductId: request.ProductId,
-		})
-		if err != nil {
-			rc.logger.WithError(err).Error("Failed to get restaurant ID for product")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-
-		// Verify ownership
-		if productRestaurantResp.RestaurantId != restaurantID {
-			rc.logger.Error("Restaurant not authorized to delete this product")
-			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to delete this product"})
-			return
-		}
+	rc.deleteProduct(c, logger, request)
+}
 
-		request.RestaurantId = restaurantID
+// AdminDeleteProductByID lets an admin delete any restaurant's product
+func (rc *RestaurantController) AdminDeleteProductByID(c *gin.Context) {
+	request, ok := bindJSON[restaurantPb.DeleteProductByIDRequest](c, rc.logger)
+	if !ok {
+		return
 	}
+	rc.deleteProduct(c, middleware.RequestLogger(c, rc.logger), request)
+}
 
+func (rc *RestaurantController) deleteProduct(c *gin.Context, logger *logrus.Entry, request *restaurantPb.DeleteProductByIDRequest) {
 	if strings.TrimSpace(request.ProductId) == "" {
-		rc.logger.Error("Product ID is required")
+		logger.Error("Product ID is required")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Product ID is required"})
 		return
 	}
 
-	response, err := rc.restaurantClient.DeleteProductByID(context.Background(), &request)
+	ctx, cancel := rc.backendContext(c)
+	defer cancel()
+
+	response, err := rc.restaurantClient.DeleteProductByID(ctx, request)
 	if err != nil {
-		rc.logger.WithError(err).Error("Failed to delete product")
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithError(err).Error("Failed to delete product")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	rc.invalidateProductCaches()
+
 	c.JSON(http.StatusOK, response)
 }
 
 func (rc *RestaurantController) GetProductByID(c *gin.Context) {
+	logger := middleware.RequestLogger(c, rc.logger)
 	productID := c.Query("productId")
 	request := &restaurantPb.GetProductByIDRequest{
 		ProductId: productID,
 	}
 
-	response, err := rc.restaurantClient.GetProductByID(context.Background(), request)
+	ctx, cancel := rc.backendContext(c)
+	defer cancel()
+
+	response, err := rc.restaurantClient.GetProductByID(ctx, request)
 	if err != nil {
-		rc.logger.WithError(err).Error("Failed to get product")
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithError(err).Error("Failed to get product")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -594,181 +835,246 @@ func (rc *RestaurantController) GetProductByID(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// IncrementProductStock lets a restaurant add stock to one of its own products
 func (rc *RestaurantController) IncrementProductStock(c *gin.Context) {
-	var request restaurantPb.IncremenentProductStockByValueRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		rc.logger.WithError(err).Error("Failed to bind increment stock request")
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	logger := middleware.RequestLogger(c, rc.logger)
+	request, ok := bindJSON[restaurantPb.IncremenentProductStockByValueRequest](c, rc.logger)
+	if !ok {
 		return
 	}
 
-	// Get role from token
-	role, exists := middleware.GetEntityRole(c)
-	if !exists {
-		rc.logger.Error("Role not found in token")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	if _, ok := rc.authorizeProductOwner(c, logger, request.ProductId, "modify the stock of"); !ok {
 		return
 	}
 
-	// If not admin, verify restaurant ownership
-	if role != middleware.RoleAdmin {
-		restaurantID, exists := middleware.GetEntityID(c)
-		if !exists {
-			rc.logger.Error("Restaurant ID not found in token")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			return
-		}
-
-		// Get restaurant ID for the product
-		productRestaurantResp, err := rc.restaurantClient.GetRestaurantIDviaProductID(context.Background(), &restaurantPb.GetRestaurantIDviaProductIDRequest{
-			ProductId: request.ProductId,
-		})
-		if err != nil {
-			rc.logger.WithError(err).Error("Failed to get restaurant ID for product")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+	rc.incrementProductStock(c, logger, request)
+}
 
-		// Verify ownership
-		if productRestaurantResp.RestaurantId != restaurantID {
-			rc.logger.Error("Restaurant not authorized to modify this product's stock")
-			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to modify this product's stock"})
-			return
-		}
+// AdminIncrementProductStock lets an admin add stock to any restaurant's product
+func (rc *RestaurantController) AdminIncrementProductStock(c *gin.Context) {
+	request, ok := bindJSON[restaurantPb.IncremenentProductStockByValueRequest](c, rc.logger)
+	if !ok {
+		return
 	}
+	rc.incrementProductStock(c, middleware.RequestLogger(c, rc.logger), request)
+}
 
+func (rc *RestaurantController) incrementProductStock(c *gin.Context, logger *logrus.Entry, request *restaurantPb.IncremenentProductStockByValueRequest) {
 	if strings.TrimSpace(request.ProductId) == "" {
-		rc.logger.Error("Product ID is required")
+		logger.Error("Product ID is required")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Product ID is required"})
 		return
 	}
 
 	if request.Value <= 0 {
-		rc.logger.Error("Invalid increment value")
+		logger.Error("Invalid increment value")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Increment value must be greater than 0"})
 		return
 	}
 
-	response, err := rc.restaurantClient.IncremenentProductStockByValue(context.Background(), &request)
+	ctx, cancel := rc.backendContext(c)
+	defer cancel()
+
+	unlock := rc.stockLocks.Lock(request.ProductId)
+	defer unlock()
+	if !rc.checkStockVersion(ctx, c, logger, request.ProductId) {
+		return
+	}
+
+	response, err := rc.restaurantClient.IncremenentProductStockByValue(ctx, request)
 	if err != nil {
-		rc.logger.WithError(err).Error("Failed to increment stock")
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithError(err).Error("Failed to increment stock")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	rc.stockVersions.Bump(request.ProductId)
+
+	rc.invalidateProductCaches()
 
 	c.JSON(http.StatusOK, response)
 }
 
+// DecrementProductStock lets a restaurant remove stock from one of its own products
 func (rc *RestaurantController) DecrementProductStock(c *gin.Context) {
-	var request restaurantPb.DecrementProductStockByValueByValueRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		rc.logger.WithError(err).Error("Failed to bind decrement stock request")
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	logger := middleware.RequestLogger(c, rc.logger)
+	request, ok := bindJSON[restaurantPb.DecrementProductStockByValueByValueRequest](c, rc.logger)
+	if !ok {
 		return
 	}
 
-	// Get role from token
-	role, exists := middleware.GetEntityRole(c)
-	if !exists {
-		rc.logger.Error("Role not found in token")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	if _, ok := rc.authorizeProductOwner(c, logger, request.ProductId, "modify the stock of"); !ok {
 		return
 	}
 
-	// If not admin, verify restaurant ownership
-	if role != middleware.RoleAdmin {
-		restaurantID, exists := middleware.GetEntityID(c)
-		if !exists {
-			rc.logger.Error("Restaurant ID not found in token")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			return
-		}
-
-		// Get restaurant ID for the product
-		productRestaurantResp, err := rc.restaurantClient.GetRestaurantIDviaProductID(context.Background(), &restaurantPb.GetRestaurantIDviaProductIDRequest{
-			ProductId: request.ProductId,
-		})
-		if err != nil {
-			rc.logger.WithError(err).Error("Failed to get restaurant ID for product")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+	rc.decrementProductStock(c, logger, request)
+}
 
-		// Verify ownership
-		if productRestaurantResp.RestaurantId != restaurantID {
-			rc.logger.Error("Restaurant not authorized to modify this product's stock")
-			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to modify this product's stock"})
-			return
-		}
+// AdminDecrementProductStock lets an admin remove stock from any restaurant's product
+func (rc *RestaurantController) AdminDecrementProductStock(c *gin.Context) {
+	request, ok := bindJSON[restaurantPb.DecrementProductStockByValueByValueRequest](c, rc.logger)
+	if !ok {
+		return
 	}
+	rc.decrementProductStock(c, middleware.RequestLogger(c, rc.logger), request)
+}
 
+func (rc *RestaurantController) decrementProductStock(c *gin.Context, logger *logrus.Entry, request *restaurantPb.DecrementProductStockByValueByValueRequest) {
 	if strings.TrimSpace(request.ProductId) == "" {
-		rc.logger.Error("Product ID is required")
+		logger.Error("Product ID is required")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Product ID is required"})
 		return
 	}
 
 	if request.Value <= 0 {
-		rc.logger.Error("Invalid decrement value")
+		logger.Error("Invalid decrement value")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Decrement value must be greater than 0"})
 		return
 	}
 
-	response, err := rc.restaurantClient.DecrementProductStockByValue(context.Background(), &request)
+	ctx, cancel := rc.backendContext(c)
+	defer cancel()
+
+	unlock := rc.stockLocks.Lock(request.ProductId)
+	defer unlock()
+	if !rc.checkStockVersion(ctx, c, logger, request.ProductId) {
+		return
+	}
+
+	response, err := rc.restaurantClient.DecrementProductStockByValue(ctx, request)
 	if err != nil {
-		rc.logger.WithError(err).Error("Failed to decrement stock")
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithError(err).Error("Failed to decrement stock")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	rc.stockVersions.Bump(request.ProductId)
+
+	rc.invalidateProductCaches()
 
 	c.JSON(http.StatusOK, response)
 }
 
+// stockVersion is the ETag of a product's stock: the number of stock changes the
+// gateway has applied, which never repeats, and the stock level, which catches
+// changes made elsewhere such as orders being placed
+func stockVersion(version uint64, stock int32) string {
+	return fmt.Sprintf(`"%d-%d"`, version, stock)
+}
+
+// checkStockVersion enforces an If-Match header on a stock change: when present, the
+// product's stock must not have changed since the client read it, otherwise 409 is
+// returned with the current stock. The caller must hold the product's stock lock
+// across the check and the change, and bump its version once the change is made.
+//
+// The check is best-effort until the restaurant service can compare and set stock
+// itself. Versions and locks are kept per gateway process, so changes through other
+// replicas are only caught when they leave a different stock level, as are order
+// placements, which change stock without taking the lock. Replicas also disagree on
+// versions, so a reading taken through one replica may get a spurious 409 on another.
+func (rc *RestaurantController) checkStockVersion(ctx context.Context, c *gin.Context, logger *logrus.Entry, productID string) bool {
+	ifMatch := strings.TrimPrefix(strings.TrimSpace(c.GetHeader("If-Match")), "W/")
+	if ifMatch == "" || ifMatch == "*" {
+		return true
+	}
+
+	stockResp, err := rc.restaurantClient.GetStockByProductID(ctx, &restaurantPb.GetStockByProductIDRequest{ProductId: productID})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return false
+		}
+		logger.WithError(err).Error("Failed to get stock for version check")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+
+	current := stockVersion(rc.stockVersions.Get(productID), stockResp.Stock)
+	if ifMatch != current {
+		logger.WithFields(logrus.Fields{
+			"productId": productID,
+			"ifMatch":   ifMatch,
+			"current":   current,
+		}).Warn("Stock version mismatch")
+		c.Header("ETag", current)
+		c.JSON(http.StatusConflict, gin.H{
+			"error":        "Stock has changed since it was read",
+			"currentStock": stockResp.Stock,
+		})
+		return false
+	}
+	return true
+}
+
 func (rc *RestaurantController) BanRestaurant(c *gin.Context) {
-	var request restaurantPb.BanRestaurantRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		rc.logger.WithError(err).Error("Failed to bind ban restaurant request")
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	logger := middleware.RequestLogger(c, rc.logger)
+	request, ok := bindJSON[restaurantPb.BanRestaurantRequest](c, rc.logger)
+	if !ok {
 		return
 	}
 
-	response, err := rc.restaurantClient.BanRestaurant(context.Background(), &request)
+	ctx, cancel := rc.backendContext(c)
+	defer cancel()
+
+	response, err := rc.restaurantClient.BanRestaurant(ctx, request)
 	if err != nil {
-		rc.logger.WithError(err).Error("Failed to ban restaurant")
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithError(err).Error("Failed to ban restaurant")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	rc.invalidateProductCaches()
+
 	c.JSON(http.StatusOK, response)
 }
 
 func (rc *RestaurantController) UnbanRestaurant(c *gin.Context) {
-	var request restaurantPb.UnbanRestaurantRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		rc.logger.WithError(err).Error("Failed to bind unban restaurant request")
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	logger := middleware.RequestLogger(c, rc.logger)
+	request, ok := bindJSON[restaurantPb.UnbanRestaurantRequest](c, rc.logger)
+	if !ok {
 		return
 	}
 
-	response, err := rc.restaurantClient.UnbanRestaurant(context.Background(), &request)
+	ctx, cancel := rc.backendContext(c)
+	defer cancel()
+
+	response, err := rc.restaurantClient.UnbanRestaurant(ctx, request)
 	if err != nil {
-		rc.logger.WithError(err).Error("Failed to unban restaurant")
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithError(err).Error("Failed to unban restaurant")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	rc.invalidateProductCaches()
+
 	c.JSON(http.StatusOK, response)
 }
 
 func (rc *RestaurantController) GetRestaurantIDviaProductID(c *gin.Context) {
+	logger := middleware.RequestLogger(c, rc.logger)
 	productID := c.Query("productId")
 	request := &restaurantPb.GetRestaurantIDviaProductIDRequest{
 		ProductId: productID,
 	}
 
-	response, err := rc.restaurantClient.GetRestaurantIDviaProductID(context.Background(), request)
+	ctx, cancel := rc.backendContext(c)
+	defer cancel()
+
+	response, err := rc.restaurantClient.GetRestaurantIDviaProductID(ctx, request)
 	if err != nil {
-		rc.logger.WithError(err).Error("Failed to get restaurant ID")
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithError(err).Error("Failed to get restaurant ID")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -776,18 +1082,429 @@ func (rc *RestaurantController) GetRestaurantIDviaProductID(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// GetRestaurantStatuses reports, for every requested restaurant, whether it is open
+// and whether it is banned, so a listing can show all its cards from one request.
+// The ban statuses are looked up concurrently, at most fanOutLimit at a time.
+func (rc *RestaurantController) GetRestaurantStatuses(c *gin.Context) {
+	logger := middleware.RequestLogger(c, rc.logger)
+	request, ok := bindJSON[model.RestaurantStatusRequest](c, rc.logger)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := rc.backendContext(c)
+	defer cancel()
+
+	now := time.Now()
+	statuses := make([]model.RestaurantStatus, len(request.RestaurantIDs))
+	utils.FanOut(len(request.RestaurantIDs), rc.fanOutLimit, func(i int) {
+		restaurantID := request.RestaurantIDs[i]
+		response, err := rc.restaurantClient.CheckRestaurantBanStatus(ctx, &restaurantPb.CheckRestaurantBanStatusRequest{RestaurantId: restaurantID})
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				statuses[i].Error = "Restaurant not found"
+				return
+			}
+			logger.WithError(err).WithField("restaurantId", restaurantID).Error("Failed to check restaurant ban status")
+			statuses[i].Error = "Status unavailable"
+			return
+		}
+		statuses[i] = model.RestaurantStatus{
+			IsOpen:   !response.IsBanned && rc.schedule.isOpenAt(restaurantID, now),
+			IsBanned: response.IsBanned,
+		}
+	})
+
+	result := make(map[string]model.RestaurantStatus, len(statuses))
+	for i, restaurantID := range request.RestaurantIDs {
+		result[restaurantID] = statuses[i]
+	}
+	c.JSON(http.StatusOK, result)
+}
+
 func (rc *RestaurantController) GetStockByProductID(c *gin.Context) {
+	logger := middleware.RequestLogger(c, rc.logger)
 	productID := c.Query("productId")
 	request := &restaurantPb.GetStockByProductIDRequest{
 		ProductId: productID,
 	}
 
-	response, err := rc.restaurantClient.GetStockByProductID(context.Background(), request)
+	ctx, cancel := rc.backendContext(c)
+	defer cancel()
+
+	// Read first, so a change made during the call leaves the ETag stale, not fresh
+	version := rc.stockVersions.Get(productID)
+	response, err := rc.restaurantClient.GetStockByProductID(ctx, request)
 	if err != nil {
-		rc.logger.WithError(err).Error("Failed to get stock")
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithError(err).Error("Failed to get stock")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Sent back in If-Match to make a stock change conditional on this reading
+	c.Header("ETag", stockVersion(version, response.Stock))
 	c.JSON(http.StatusOK, response)
 }
+
+// ExportProducts returns all of the authenticated restaurant's products as a
+// downloadable file. Supports ?format=json|csv, defaulting to json.
+func (rc *RestaurantController) ExportProducts(c *gin.Context) {
+	logger := middleware.RequestLogger(c, rc.logger)
+	restaurantID, exists := middleware.GetEntityID(c)
+	if !exists {
+		logger.Error("Restaurant ID not found in token")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", "json"))
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+
+	ctx, cancel := rc.backendContext(c)
+	defer cancel()
+
+	response, err := rc.restaurantClient.GetRestaurantProductsByID(ctx, &restaurantPb.GetRestaurantProductsByIDRequest{
+		RestaurantId: restaurantID,
+	})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithError(err).Error("Failed to get products for export")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	products := make([]model.MenuProduct, len(response.Products))
+	for i, product := range response.Products {
+		products[i] = model.MenuProduct{
+			Name:        product.Name,
+			Description: product.Description,
+			Category:    product.Category,
+			Price:       product.Price,
+			Stock:       product.Stock,
+		}
+	}
+
+	exportedAt := time.Now().UTC()
+	filename := fmt.Sprintf("foodbuddy-menu-%s-%s.%s", restaurantID, exportedAt.Format("20060102"), format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := utils.WriteMenuCSV(c.Writer, products); err != nil {
+			// Headers are already sent, so the client sees a truncated file
+			logger.WithError(err).Error("Failed to write menu CSV")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, model.MenuExport{
+		RestaurantID: restaurantID,
+		ExportedAt:   exportedAt.Format(time.RFC3339),
+		Products:     products,
+	})
+}
+
+// ImportProducts adds the products of a menu export to the authenticated restaurant.
+// Supports ?format=json|csv, defaulting to json, and ?mode=upsert to update products
+// whose name already exists instead of rejecting them. Every row is validated and
+// imported on its own, and the response reports the outcome of each.
+func (rc *RestaurantController) ImportProducts(c *gin.Context) {
+	logger := middleware.RequestLogger(c, rc.logger)
+	restaurantID, exists := middleware.GetEntityID(c)
+	if !exists {
+		logger.Error("Restaurant ID not found in token")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	mode := strings.ToLower(c.Query("mode"))
+	if mode != "" && mode != "upsert" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be upsert when set"})
+		return
+	}
+	upsert := mode == "upsert"
+
+	var rows []utils.MenuCSVRow
+	switch strings.ToLower(c.DefaultQuery("format", "json")) {
+	case "json":
+		request, ok := bindJSON[model.MenuImportRequest](c, rc.logger)
+		if !ok {
+			return
+		}
+		rows = make([]utils.MenuCSVRow, len(request.Products))
+		for i, product := range request.Products {
+			rows[i].Product = product
+		}
+	case "csv":
+		var err error
+		rows, err = utils.ReadMenuCSV(c.Request.Body, model.MaxMenuImportRows)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid CSV: " + err.Error()})
+			return
+		}
+		if len(rows) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "CSV has no products"})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+
+	ctx, cancel := rc.backendContext(c)
+	defer cancel()
+
+	productsResp, err := rc.restaurantClient.GetRestaurantProductsByID(ctx, &restaurantPb.GetRestaurantProductsByIDRequest{
+		RestaurantId: restaurantID,
+	})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithError(err).Error("Failed to get products for menu import")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	existing := make(map[string]string, len(productsResp.Products))
+	for _, product := range productsResp.Products {
+		existing[strings.ToLower(strings.TrimSpace(product.Name))] = product.ProductId
+	}
+
+	// Rows are validated up front so that a name repeated in the import is only imported once
+	results := make([]model.MenuImportRowResult, len(rows))
+	seen := make(map[string]bool, len(rows))
+	for i, row := range rows {
+		results[i] = model.MenuImportRowResult{Row: i + 1, Name: row.Product.Name}
+		key := strings.ToLower(strings.TrimSpace(row.Product.Name))
+		err := row.Err
+		if err == nil {
+			err = rc.validateProduct(row.Product.Name, row.Product.Price, row.Product.Stock)
+		}
+		switch {
+		case err != nil:
+			results[i].Error = err.Error()
+		case seen[key]:
+			results[i].Error = "Product name appears more than once in the import"
+		case existing[key] != "" && !upsert:
+			results[i].Error = model.ErrDuplicateProductName
+		}
+		if results[i].Error != "" {
+			results[i].Action = model.MenuImportFailed
+			continue
+		}
+		// Only a row that will be imported claims the name, so an invalid row does not
+		// reject a valid one further down
+		seen[key] = true
+	}
+
+	utils.FanOut(len(rows), rc.fanOutLimit, func(i int) {
+		if results[i].Action == model.MenuImportFailed {
+			return
+		}
+		product := rows[i].Product
+
+		if productID := existing[strings.ToLower(strings.TrimSpace(product.Name))]; productID != "" {
+			_, err := rc.restaurantClient.EditProduct(ctx, &restaurantPb.EditProductRequest{
+				ProductId:    productID,
+				RestaurantId: restaurantID,
+				Name:         product.Name,
+				Description:  product.Description,
+				Price:        product.Price,
+				Stock:        product.Stock,
+				Category:     product.Category,
+			})
+			if err != nil {
+				results[i].Action, results[i].Error = model.MenuImportFailed, err.Error()
+				return
+			}
+			rc.stockVersions.Bump(productID)
+			results[i].Action, results[i].ProductID = model.MenuImportUpdated, productID
+			return
+		}
+
+		response, err := rc.restaurantClient.AddProduct(ctx, &restaurantPb.AddProductRequest{
+			RestaurantId: restaurantID,
+			Name:         product.Name,
+			Description:  product.Description,
+			Price:        product.Price,
+			Stock:        product.Stock,
+			Category:     product.Category,
+		})
+		if err != nil {
+			results[i].Action, results[i].Error = model.MenuImportFailed, err.Error()
+			return
+		}
+		results[i].Action, results[i].ProductID = model.MenuImportCreated, response.ProductId
+	})
+
+	summary := model.MenuImportResult{Rows: results}
+	for _, result := range results {
+		switch result.Action {
+		case model.MenuImportCreated:
+			summary.Created++
+		case model.MenuImportUpdated:
+			summary.Updated++
+		default:
+			summary.Failed++
+		}
+	}
+	if summary.Created+summary.Updated > 0 {
+		rc.invalidateProductCaches()
+	}
+
+	logger.WithFields(logrus.Fields{
+		"restaurantId": restaurantID,
+		"created":      summary.Created,
+		"updated":      summary.Updated,
+		"failed":       summary.Failed,
+	}).Info("Menu import completed")
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetInventoryValue values the authenticated restaurant's stock at current prices,
+// per product and in total. Results are cached per restaurant.
+func (rc *RestaurantController) GetInventoryValue(c *gin.Context) {
+	logger := middleware.RequestLogger(c, rc.logger)
+	restaurantID, exists := middleware.GetEntityID(c)
+	if !exists {
+		logger.Error("Restaurant ID not found in token")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if cached, ok := rc.valuationCache.Get(restaurantID); ok {
+		c.Header("X-Cache", "HIT")
+		c.JSON(http.StatusOK, cached)
+		return
+	}
+
+	ctx, cancel := rc.backendContext(c)
+	defer cancel()
+
+	response, err := rc.restaurantClient.GetRestaurantProductsByID(ctx, &restaurantPb.GetRestaurantProductsByIDRequest{
+		RestaurantId: restaurantID,
+	})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithError(err).Error("Failed to get products for inventory valuation")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	valuation := &model.InventoryValuation{
+		RestaurantID: restaurantID,
+		Products:     make([]model.InventoryValueItem, 0, len(response.Products)),
+	}
+	for _, product := range response.Products {
+		stock := max(product.Stock, 0)
+		value := product.Price * float64(stock)
+		valuation.Products = append(valuation.Products, model.InventoryValueItem{
+			ProductID: product.ProductId,
+			Name:      product.Name,
+			Price:     product.Price,
+			Stock:     stock,
+			Value:     value,
+		})
+		valuation.TotalUnits += int64(stock)
+		valuation.TotalValue += value
+	}
+
+	rc.valuationCache.Set(restaurantID, valuation)
+
+	c.Header("X-Cache", "MISS")
+	c.JSON(http.StatusOK, valuation)
+}
+
+// GetInventory lists the authenticated restaurant's products with their stock levels.
+// Supports ?availability=in_stock|low_stock|out_of_stock and ?sort=stock_asc|stock_desc.
+func (rc *RestaurantController) GetInventory(c *gin.Context) {
+	logger := middleware.RequestLogger(c, rc.logger)
+	restaurantID, exists := middleware.GetEntityID(c)
+	if !exists {
+		logger.Error("Restaurant ID not found in token")
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse("Restaurant ID not found in token", nil))
+		return
+	}
+
+	availability := c.Query("availability")
+	switch availability {
+	case "", "in_stock", "low_stock", "out_of_stock":
+	default:
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrInvalidAvailabilityFilter, nil))
+		return
+	}
+
+	sortOrder := c.Query("sort")
+	switch sortOrder {
+	case "", "stock_asc", "stock_desc":
+	default:
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrInvalidSortOrder, nil))
+		return
+	}
+
+	ctx, cancel := rc.backendContext(c)
+	defer cancel()
+
+	response, err := rc.restaurantClient.GetRestaurantProductsByID(ctx, &restaurantPb.GetRestaurantProductsByIDRequest{
+		RestaurantId: restaurantID,
+	})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithError(err).Error("Failed to get restaurant inventory")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.ErrFailedRetrieveInventory, err))
+		return
+	}
+
+	inventory := make([]model.InventoryProduct, 0, len(response.Products))
+	for _, product := range response.Products {
+		isLowStock := product.Stock > 0 && product.Stock <= rc.lowStockThreshold
+
+		switch availability {
+		case "in_stock":
+			if product.Stock <= 0 {
+				continue
+			}
+		case "low_stock":
+			if !isLowStock {
+				continue
+			}
+		case "out_of_stock":
+			if product.Stock > 0 {
+				continue
+			}
+		}
+
+		inventory = append(inventory, model.InventoryProduct{
+			ProductID:   product.ProductId,
+			Name:        product.Name,
+			Description: product.Description,
+			Category:    product.Category,
+			Price:       product.Price,
+			Stock:       product.Stock,
+			IsLowStock:  isLowStock,
+		})
+	}
+
+	switch sortOrder {
+	case "stock_asc":
+		sort.SliceStable(inventory, func(i, j int) bool { return inventory[i].Stock < inventory[j].Stock })
+	case "stock_desc":
+		sort.SliceStable(inventory, func(i, j int) bool { return inventory[i].Stock > inventory[j].Stock })
+	}
+
+	c.JSON(http.StatusOK, model.SuccessResponse(model.MsgInventoryRetrieved, inventory))
+}
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	restaurantPb "github.com/liju-github/CentralisedFoodbuddyMicroserviceProto/Restaurant"
+	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
+	"github.com/liju-github/FoodBuddyAPIGateway/model"
+	"github.com/liju-github/FoodBuddyAPIGateway/utils"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// fakeStockClient keeps the stock of a single product in memory
+type fakeStockClient struct {
+	restaurantPb.RestaurantServiceClient
+	mutex sync.Mutex
+	stock int32
+}
+
+func (f *fakeStockClient) GetStockByProductID(context.Context, *restaurantPb.GetStockByProductIDRequest, ...grpc.CallOption) (*restaurantPb.GetStockByProductIDResponse, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return &restaurantPb.GetStockByProductIDResponse{Stock: f.stock}, nil
+}
+
+func (f *fakeStockClient) IncremenentProductStockByValue(_ context.Context, req *restaurantPb.IncremenentProductStockByValueRequest, _ ...grpc.CallOption) (*restaurantPb.IncremenentProductStockByValueResponse, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.stock += req.Value
+	return &restaurantPb.IncremenentProductStockByValueResponse{}, nil
+}
+
+func (f *fakeStockClient) DecrementProductStockByValue(_ context.Context, req *restaurantPb.DecrementProductStockByValueByValueRequest, _ ...grpc.CallOption) (*restaurantPb.DecrementProductStockByValueResponse, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.stock -= req.Value
+	return &restaurantPb.DecrementProductStockByValueResponse{}, nil
+}
+
+func TestStockIfMatchDetectsABA(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rc := &RestaurantController{
+		restaurantClient: &fakeStockClient{stock: 10},
+		logger:           logrus.New(),
+		timeout:          time.Second,
+		listingCache:     utils.NoopCache{},
+		valuationCache:   utils.NoopCache{},
+		catalogVersion:   utils.NewCatalogVersion(),
+		stockLocks:       middleware.NewKeyedMutex(),
+		stockVersions:    utils.NewStockVersions(),
+	}
+	router := gin.New()
+	router.GET("/stock", rc.GetStockByProductID)
+	router.PUT("/stock/increment", rc.AdminIncrementProductStock)
+	router.PUT("/stock/decrement", rc.AdminDecrementProductStock)
+
+	change := func(path, ifMatch string) int {
+		req := httptest.NewRequest(http.MethodPut, path, strings.NewReader(`{"productId":"p1","value":1}`))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/stock?productId=p1", nil))
+	etag := recorder.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("stock reading has no ETag")
+	}
+
+	// Another client changes the stock and changes it back
+	if code := change("/stock/increment", ""); code != http.StatusOK {
+		t.Fatalf("increment: got %d", code)
+	}
+	if code := change("/stock/decrement", ""); code != http.StatusOK {
+		t.Fatalf("decrement: got %d", code)
+	}
+
+	if code := change("/stock/decrement", etag); code != http.StatusConflict {
+		t.Errorf("change with a stale ETag at the same stock level: got %d, want 409", code)
+	}
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/stock?productId=p1", nil))
+	if code := change("/stock/decrement", recorder.Header().Get("ETag")); code != http.StatusOK {
+		t.Errorf("change with a fresh ETag: got %d, want 200", code)
+	}
+}
+
+// fakeMenuClient starts with an empty menu and accepts every new product
+type fakeMenuClient struct {
+	restaurantPb.RestaurantServiceClient
+}
+
+func (fakeMenuClient) GetRestaurantProductsByID(context.Context, *restaurantPb.GetRestaurantProductsByIDRequest, ...grpc.CallOption) (*restaurantPb.GetRestaurantProductsByIDResponse, error) {
+	return &restaurantPb.GetRestaurantProductsByIDResponse{}, nil
+}
+
+func (fakeMenuClient) AddProduct(_ context.Context, req *restaurantPb.AddProductRequest, _ ...grpc.CallOption) (*restaurantPb.AddProductResponse, error) {
+	return &restaurantPb.AddProductResponse{ProductId: "id-" + req.Name}, nil
+}
+
+func TestImportInvalidRowDoesNotClaimName(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rc := &RestaurantController{
+		restaurantClient: fakeMenuClient{},
+		logger:           logrus.New(),
+		timeout:          time.Second,
+		listingCache:     utils.NoopCache{},
+		valuationCache:   utils.NoopCache{},
+		catalogVersion:   utils.NewCatalogVersion(),
+		stockLocks:       middleware.NewKeyedMutex(),
+		stockVersions:    utils.NewStockVersions(),
+	}
+	router := gin.New()
+	router.POST("/import", func(c *gin.Context) {
+		c.Set(middleware.EntityID, "r1")
+		rc.ImportProducts(c)
+	})
+
+	body := `{"products":[{"name":"Dosa","price":0},{"name":"Dosa","price":50},{"name":"dosa","price":60}]}`
+	req := httptest.NewRequest(http.MethodPost, "/import", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("import: got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var result model.MenuImportResult
+	if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{model.MenuImportFailed, model.MenuImportCreated, model.MenuImportFailed}
+	for i, row := range result.Rows {
+		if row.Action != want[i] {
+			t.Errorf("row %d: got %s (%s), want %s", row.Row, row.Action, row.Error, want[i])
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	config "github.com/liju-github/FoodBuddyAPIGateway/configs"
+	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
+	"github.com/liju-github/FoodBuddyAPIGateway/model"
+	"github.com/sirupsen/logrus"
+)
+
+// TokenInfo reports when the caller's token was issued and when it expires, so
+// clients can schedule a refresh without decoding the JWT. Must run after
+// JWTAuthMiddleware.
+func TokenInfo(c *gin.Context) {
+	id, _ := middleware.GetEntityID(c)
+	role, _ := middleware.GetEntityRole(c)
+	expiresAt, ok := c.Get(middleware.TokenExpiryKey)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse("Token information not found", nil))
+		return
+	}
+
+	info := model.TokenInfo{
+		ID:               id,
+		Role:             role,
+		ExpiresAt:        expiresAt.(time.Time).UTC(),
+		SecondsRemaining: int64(time.Until(expiresAt.(time.Time)).Seconds()),
+	}
+	if issuedAt, ok := c.Get(middleware.TokenIssuedKey); ok {
+		issued := issuedAt.(time.Time).UTC()
+		info.IssuedAt = &issued
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// generateTokenWithRetry calls generate and, if it fails, retries once with a fresh
+// signing attempt before giving up. Both failed attempts are logged.
+func generateTokenWithRetry(generate func(ID string) (string, error), ID string, logger *logrus.Entry) (string, error) {
+	token, err := generate(ID)
+	if err == nil {
+		return token, nil
+	}
+	logger.WithFields(logrus.Fields{
+		"id":      ID,
+		"attempt": 1,
+		"error":   err.Error(),
+	}).Warn("Token generation failed, retrying")
+
+	token, err = generate(ID)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"id":      ID,
+			"attempt": 2,
+			"error":   err.Error(),
+		}).Error("Token generation retry failed")
+		return "", err
+	}
+	return token, nil
+}
+
+// sessionTokens issues the access and refresh tokens of one role's sessions. Both
+// carry the Unix time of the login that started the session, so refreshing can
+// be cut off once the session reaches its maximum lifetime.
+type sessionTokens struct {
+	role        string
+	tokens      *middleware.TokenService
+	refreshTTL  time.Duration
+	maxLifetime time.Duration
+	cookies     authCookies
+	logger      *logrus.Logger
+}
+
+func newSessionTokens(role string, tokens *middleware.TokenService, cfg config.Config, logger *logrus.Logger) sessionTokens {
+	return sessionTokens{
+		role:        role,
+		tokens:      tokens,
+		refreshTTL:  cfg.RefreshTokenTTL,
+		maxLifetime: cfg.MaxSessionLifetime,
+		cookies:     newAuthCookies(cfg),
+		logger:      logger,
+	}
+}
+
+// issue signs a token pair for ID in the session started at sessionStart. A fresh
+// login passes the current time.
+func (s sessionTokens) issue(ID string, sessionStart int64, logger *logrus.Entry) (model.SessionTokens, error) {
+	access, err := generateTokenWithRetry(func(ID string) (string, error) {
+		return s.tokens.GenerateForSession(ID, s.role, sessionStart)
+	}, ID, logger)
+	if err != nil {
+		return model.SessionTokens{}, err
+	}
+
+	refresh, err := generateTokenWithRetry(func(ID string) (string, error) {
+		return s.tokens.GenerateRefresh(ID, s.role, sessionStart, s.refreshTTL)
+	}, ID, logger)
+	if err != nil {
+		return model.SessionTokens{}, err
+	}
+
+	return model.SessionTokens{
+		Token:        access,
+		RefreshToken: refresh,
+		ExpiresIn:    int64(s.tokens.AccessTTL().Seconds()),
+	}, nil
+}
+
+// refresh exchanges a refresh token for a new token pair in the same session. Once
+// the session is older than its maximum lifetime the client has to log in again.
+func (s sessionTokens) refresh(c *gin.Context) {
+	logger := middleware.RequestLogger(c, s.logger)
+	request, ok := bindJSON[model.RefreshTokenRequest](c, s.logger)
+	if !ok {
+		return
+	}
+
+	claims, err := s.tokens.ParseRefresh(request.RefreshToken)
+	if err != nil || claims.Role != s.role {
+		logger.WithField("role", s.role).Warn("Rejected refresh token")
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.ErrInvalidRefreshToken, nil))
+		return
+	}
+	if claims.SessionExpired(s.maxLifetime) {
+		logger.WithFields(logrus.Fields{
+			"id":   claims.ID,
+			"role": s.role,
+		}).Info("Refresh refused, session expired")
+		c.JSON(http.StatusUnauthorized, model.ErrorResponseWithCode(model.CodeSessionExpired, model.ErrSessionExpired, nil))
+		return
+	}
+	// Each refresh token is good for one exchange. It is spent before the new pair is
+	// issued, so concurrent requests with the same token cannot both succeed.
+	if !middleware.ConsumeToken(claims.RegisteredClaims.ID, claims.ExpiresAt.Time) {
+		logger.WithField("role", s.role).Warn("Rejected reused refresh token")
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.ErrInvalidRefreshToken, nil))
+		return
+	}
+
+	tokens, err := s.issue(claims.ID, claims.SessionStart, logger)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.ErrFailedGenerateToken, err))
+		return
+	}
+	s.cookies.set(c, tokens.Token)
+
+	c.JSON(http.StatusOK, model.SuccessResponse(model.MsgTokenRefreshed, tokens))
+}
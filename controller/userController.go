@@ -2,21 +2,24 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"regexp"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"github.com/golang-jwt/jwt/v5"
 	User "github.com/liju-github/CentralisedFoodbuddyMicroserviceProto/User"
 	config "github.com/liju-github/FoodBuddyAPIGateway/configs"
 	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
 	"github.com/liju-github/FoodBuddyAPIGateway/model"
+	"github.com/liju-github/FoodBuddyAPIGateway/utils"
 	"github.com/sirupsen/logrus"
 )
 
@@ -24,7 +27,21 @@ type UserController struct {
 	userClient User.UserServiceClient
 	validator  *validator.Validate
 	logger     *logrus.Logger
-	jwtSecret  []byte
+	tokens     *middleware.TokenService
+	timeout    time.Duration
+
+	requireVerificationBeforeLogin bool
+
+	verificationCodeLength int
+	verificationCodeRegex  *regexp.Regexp
+
+	serviceablePincodePrefixes []string
+	impersonationTokenTTL      time.Duration
+	fanOutLimit                int
+	passwordPolicy             utils.PasswordPolicy
+	blockedEmailDomains        utils.EmailDomainDenyList
+	cookies                    authCookies
+	sessions                   sessionTokens
 }
 
 // Validation functions
@@ -32,39 +49,100 @@ func (uc *UserController) validateEmail(email string) bool {
 	return emailRegex.MatchString(email)
 }
 
-func (uc *UserController) validatePassword(password string) bool {
-	return passwordRegex.MatchString(password)
+func (uc *UserController) validatePassword(password string) error {
+	return uc.passwordPolicy.Validate(password)
 }
 
 func (uc *UserController) validateName(name string) bool {
 	return nameRegex.MatchString(name)
 }
 
-func (uc *UserController) validatePhone(phone uint64) bool {
-	return phoneRegex.MatchString(fmt.Sprint(phone))
-}
-
 func (uc *UserController) validatePincode(pincode string) bool {
 	return pincodeRegex.MatchString(pincode)
 }
 
+// isServiceablePincode reports whether the pincode falls in a served region.
+// Every pincode is serviceable when no prefixes are configured.
+func (uc *UserController) isServiceablePincode(pincode string) bool {
+	if len(uc.serviceablePincodePrefixes) == 0 {
+		return true
+	}
+	for _, prefix := range uc.serviceablePincodePrefixes {
+		if strings.HasPrefix(pincode, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (uc *UserController) validateVerificationCode(code string) error {
+	if !uc.verificationCodeRegex.MatchString(code) {
+		return fmt.Errorf("verification code must be %d numeric digits", uc.verificationCodeLength)
+	}
+	return nil
+}
+
 func (uc *UserController) validateAddress(address model.Address) error {
+	if problems := uc.addressProblems(address); len(problems) > 0 {
+		return errors.New(problems[0].Message)
+	}
+	return nil
+}
+
+// addressProblems lists every field of the address that validateAddress would reject
+func (uc *UserController) addressProblems(address model.Address) []model.FieldError {
+	var problems []model.FieldError
 	if strings.TrimSpace(address.StreetName) == "" {
-		return fmt.Errorf("street name cannot be empty")
+		problems = append(problems, model.FieldError{Field: "streetName", Message: "street name cannot be empty"})
 	}
 	if strings.TrimSpace(address.Locality) == "" {
-		return fmt.Errorf("locality cannot be empty")
+		problems = append(problems, model.FieldError{Field: "locality", Message: "locality cannot be empty"})
 	}
 	if strings.TrimSpace(address.State) == "" {
-		return fmt.Errorf("state cannot be empty")
+		problems = append(problems, model.FieldError{Field: "state", Message: "state cannot be empty"})
 	}
-	if !uc.validatePincode(address.Pincode) {
-		return fmt.Errorf("invalid pincode format")
+	switch {
+	case !uc.validatePincode(address.Pincode):
+		problems = append(problems, model.FieldError{Field: "pincode", Message: "invalid pincode format"})
+	case !uc.isServiceablePincode(address.Pincode):
+		problems = append(problems, model.FieldError{Field: "pincode", Message: model.ErrUnserviceablePincode})
 	}
-	return nil
+	return problems
 }
 
-func NewUserController(userClient User.UserServiceClient) *UserController {
+// normalizeAddress trims every field and collapses repeated spaces, title-cases the
+// locality and state, and drops spaces from the pincode
+func normalizeAddress(address model.Address) model.Address {
+	return model.Address{
+		StreetName: strings.Join(strings.Fields(address.StreetName), " "),
+		Locality:   titleCase(address.Locality),
+		State:      titleCase(address.State),
+		Pincode:    strings.Join(strings.Fields(address.Pincode), ""),
+	}
+}
+
+// titleCase capitalizes the first letter of every word and lowercases the rest
+func titleCase(value string) string {
+	words := strings.Fields(value)
+	for i, word := range words {
+		runes := []rune(strings.ToLower(word))
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
+
+// toUserAddress converts a gateway address into the user service format
+func toUserAddress(address model.Address) *User.Address {
+	return &User.Address{
+		StreetName: address.StreetName,
+		Locality:   address.Locality,
+		State:      address.State,
+		Pincode:    address.Pincode,
+	}
+}
+
+func NewUserController(cfg config.Config, userClient User.UserServiceClient, tokens *middleware.TokenService, blockedEmailDomains utils.EmailDomainDenyList) *UserController {
 	validate := validator.New()
 	logger := logrus.New()
 
@@ -102,53 +180,61 @@ func NewUserController(userClient User.UserServiceClient) *UserController {
 	logger = logger.WithFields(logrus.Fields{
 		"service": "api_gateway",
 		"version": "1.0",
-		"env":     config.LoadConfig().Environment,
+		"env":     cfg.Environment,
 	}).Logger
 
-	// Get JWT secret from environment variable or use a default for development
-	jwtSecret := []byte(config.LoadConfig().JWTSecretKey)
+	codeLength := cfg.VerificationCodeLength
 
 	return &UserController{
 		userClient: userClient,
 		validator:  validate,
 		logger:     logger,
-		jwtSecret:  jwtSecret,
+		tokens:     tokens,
+		timeout:    cfg.UserTimeout,
+
+		requireVerificationBeforeLogin: cfg.RequireVerificationBeforeLogin,
+
+		verificationCodeLength: codeLength,
+		verificationCodeRegex:  regexp.MustCompile(fmt.Sprintf(`^\d{%d}$`, codeLength)),
+
+		serviceablePincodePrefixes: cfg.ServiceablePincodePrefixes,
+		impersonationTokenTTL:      cfg.ImpersonationTokenTTL,
+		fanOutLimit:                cfg.MaxBackendConcurrency,
+		passwordPolicy:             passwordPolicy(cfg),
+		blockedEmailDomains:        blockedEmailDomains,
+		cookies:                    newAuthCookies(cfg),
+		sessions:                   newSessionTokens(middleware.RoleUser, tokens, cfg, logger),
 	}
 }
 
-func (uc *UserController) generateToken(ID string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":      ID,
-		"role":    middleware.RoleUser,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
-		"created": time.Now().Unix(),
-	})
+// backendContext returns a context for a backend call made on behalf of c, bounded by
+// the User service timeout and cancelled if the client disconnects
+func (uc *UserController) backendContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	return middleware.WithRequestTimeout(c, uc.timeout)
+}
 
-	return token.SignedString(uc.jwtSecret)
+// RefreshToken exchanges a user refresh token for a new access and refresh token
+func (uc *UserController) RefreshToken(c *gin.Context) {
+	uc.sessions.refresh(c)
 }
 
 // Login handles user authentication
 func (uc *UserController) Login(c *gin.Context) {
-	var request model.LoginRequest
-
-	if err := c.ShouldBindJSON(&request); err != nil {
-		uc.logger.WithFields(logrus.Fields{
-			"error": err.Error(),
-			"path":  "/auth/user/login",
-		}).Error("Failed to bind login request")
-		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrInvalidRequestFormat, err))
+	logger := middleware.RequestLogger(c, uc.logger)
+	request, ok := bindJSON[model.LoginRequest](c, uc.logger)
+	if !ok {
 		return
 	}
 
 	// Log sanitized request (excluding password)
-	uc.logger.WithFields(logrus.Fields{
+	logger.WithFields(logrus.Fields{
 		"email": request.Email,
 		"path":  "/auth/user/login",
 	}).Info("Processing login request")
 
 	// Additional validation
 	if !uc.validateEmail(request.Email) {
-		uc.logger.WithFields(logrus.Fields{
+		logger.WithFields(logrus.Fields{
 			"email": request.Email,
 			"path":  "/auth/user/login",
 		}).Warn("Invalid email format")
@@ -156,59 +242,69 @@ func (uc *UserController) Login(c *gin.Context) {
 		return
 	}
 
-	if !uc.validatePassword(request.Password) {
-		uc.logger.WithField("email", request.Email).Warn("Invalid password format")
-		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrPasswordTooShort, nil))
+	// Only the length bounds apply at login, so tightening the complexity rules
+	// does not lock out accounts created before the change
+	if err := uc.passwordPolicy.ValidateLength(request.Password); err != nil {
+		logger.WithField("email", request.Email).Warn("Invalid password format")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(err.Error(), nil))
 		return
 	}
 
-	resp, err := uc.userClient.UserLogin(context.Background(), &User.UserLoginRequest{
+	ctx, cancel := uc.backendContext(c)
+	defer cancel()
+
+	resp, err := uc.userClient.UserLogin(ctx, &User.UserLoginRequest{
 		Email:    request.Email,
 		Password: request.Password,
 	})
 
-	resp.Token, err = uc.generateToken(resp.UserId)
 	if err != nil {
-		uc.logger.WithFields(logrus.Fields{
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithFields(logrus.Fields{
 			"email": request.Email,
 			"error": err.Error(),
-		}).Error(model.ErrFailedGenerateToken)
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.ErrFailedGenerateToken, err))
+		}).Error("Login failed")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.ErrLoginFailed, err))
 		return
 	}
 
+	// A fresh login starts a new session
+	tokens, err := uc.sessions.issue(resp.UserId, time.Now().Unix(), logger)
 	if err != nil {
-		uc.logger.WithFields(logrus.Fields{
+		logger.WithFields(logrus.Fields{
 			"email": request.Email,
 			"error": err.Error(),
-		}).Error("Login failed")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.ErrLoginFailed, err))
+		}).Error(model.ErrFailedGenerateToken)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.ErrFailedGenerateToken, err))
 		return
 	}
+	resp.Token = tokens.Token
+	uc.cookies.set(c, resp.Token)
 
-	uc.logger.WithFields(logrus.Fields{
+	logger.WithFields(logrus.Fields{
 		"email":  request.Email,
 		"userId": resp.UserId,
 	}).Info("Login successful")
 
-	c.JSON(http.StatusOK, model.SuccessResponse("Login successful", resp))
+	c.JSON(http.StatusOK, model.SuccessResponse("Login successful", struct {
+		*User.UserLoginResponse
+		RefreshToken string `json:"refreshToken"`
+		ExpiresIn    int64  `json:"expiresIn"`
+	}{resp, tokens.RefreshToken, tokens.ExpiresIn}))
 }
 
 // Signup handles user registration
 func (uc *UserController) Signup(c *gin.Context) {
-	var request model.SignupRequest
-
-	if err := c.ShouldBindJSON(&request); err != nil {
-		uc.logger.WithFields(logrus.Fields{
-			"error": err.Error(),
-			"path":  "/auth/user/signup",
-		}).Error("Failed to bind signup request")
-		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrInvalidRequestFormat, err))
+	logger := middleware.RequestLogger(c, uc.logger)
+	request, ok := bindJSON[model.SignupRequest](c, uc.logger)
+	if !ok {
 		return
 	}
 
 	// Log sanitized request (excluding password)
-	uc.logger.WithFields(logrus.Fields{
+	logger.WithFields(logrus.Fields{
 		"email":       request.Email,
 		"firstName":   request.FirstName,
 		"lastName":    request.LastName,
@@ -224,19 +320,25 @@ func (uc *UserController) Signup(c *gin.Context) {
 
 	// Validate all fields
 	if !uc.validateEmail(request.Email) {
-		uc.logger.WithField("email", request.Email).Warn("Invalid email format")
+		logger.WithField("email", request.Email).Warn("Invalid email format")
 		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrInvalidEmailFormat, nil))
 		return
 	}
 
-	if !uc.validatePassword(request.Password) {
-		uc.logger.WithField("email", request.Email).Warn("Invalid password format")
-		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrPasswordTooShort, nil))
+	if uc.blockedEmailDomains.Blocked(request.Email) {
+		logger.WithField("email", request.Email).Warn("Signup from blocked email domain")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrEmailDomainNotAllowed, nil))
+		return
+	}
+
+	if err := uc.validatePassword(request.Password); err != nil {
+		logger.WithField("email", request.Email).Warn("Invalid password format")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(err.Error(), nil))
 		return
 	}
 
 	if !uc.validateName(request.FirstName) || !uc.validateName(request.LastName) {
-		uc.logger.WithFields(logrus.Fields{
+		logger.WithFields(logrus.Fields{
 			"email":     request.Email,
 			"firstName": request.FirstName,
 			"lastName":  request.LastName,
@@ -245,8 +347,9 @@ func (uc *UserController) Signup(c *gin.Context) {
 		return
 	}
 
-	if !uc.validatePhone(request.PhoneNumber) {
-		uc.logger.WithFields(logrus.Fields{
+	phoneNumber, err := request.PhoneNumber.Uint64()
+	if err != nil {
+		logger.WithFields(logrus.Fields{
 			"email":       request.Email,
 			"phoneNumber": request.PhoneNumber,
 		}).Warn("Invalid phone number format")
@@ -255,7 +358,7 @@ func (uc *UserController) Signup(c *gin.Context) {
 	}
 
 	if err := uc.validateAddress(request.Address); err != nil {
-		uc.logger.WithFields(logrus.Fields{
+		logger.WithFields(logrus.Fields{
 			"email":   request.Email,
 			"address": request.Address,
 			"error":   err.Error(),
@@ -271,18 +374,19 @@ func (uc *UserController) Signup(c *gin.Context) {
 		Username:    request.FirstName + " " + request.LastName,
 		FirstName:   request.FirstName,
 		LastName:    request.LastName,
-		PhoneNumber: request.PhoneNumber,
-		Address: &User.Address{
-			StreetName: request.Address.StreetName,
-			Locality:   request.Address.Locality,
-			State:      request.Address.State,
-			Pincode:    request.Address.Pincode,
-		},
+		PhoneNumber: phoneNumber,
+		Address:     toUserAddress(request.Address),
 	}
 
-	resp, err := uc.userClient.UserSignup(context.Background(), grpcRequest)
+	ctx, cancel := uc.backendContext(c)
+	defer cancel()
+
+	resp, err := uc.userClient.UserSignup(ctx, grpcRequest)
 	if err != nil {
-		uc.logger.WithFields(logrus.Fields{
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithFields(logrus.Fields{
 			"email": request.Email,
 			"error": err.Error(),
 		}).Error("Signup failed")
@@ -292,40 +396,65 @@ func (uc *UserController) Signup(c *gin.Context) {
 
 	log.Println("response", resp)
 
+	// Deployments requiring verification issue no token until the user verifies and logs in
+	if uc.requireVerificationBeforeLogin {
+		resp.Token = ""
+		logger.WithFields(logrus.Fields{
+			"email":  request.Email,
+			"userId": resp.UserId,
+		}).Info("Signup successful, pending email verification")
+
+		c.JSON(http.StatusOK, model.SuccessResponse(model.MsgSignupPendingVerification, resp))
+		return
+	}
+
 	// Generate JWT token
-	resp.Token, err = uc.generateToken(resp.UserId)
+	tokens, err := uc.sessions.issue(resp.UserId, time.Now().Unix(), logger)
 	if err != nil {
-		uc.logger.WithFields(logrus.Fields{
+		logger.WithFields(logrus.Fields{
 			"userId": resp.UserId,
 			"error":  err.Error(),
 		}).Error("Failed to generate token")
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse("Failed to generate token", err))
 		return
 	}
+	resp.Token = tokens.Token
+	uc.cookies.set(c, resp.Token)
 
-	uc.logger.WithFields(logrus.Fields{
+	logger.WithFields(logrus.Fields{
 		"email":  request.Email,
 		"userId": resp.UserId,
 	}).Info("Signup successful")
 
-	c.JSON(http.StatusOK, model.SuccessResponse("Signup successful", resp))
+	c.JSON(http.StatusOK, model.SuccessResponse(model.MsgSignupSuccessful, struct {
+		*User.UserSignupResponse
+		RefreshToken string `json:"refreshToken"`
+		ExpiresIn    int64  `json:"expiresIn"`
+	}{resp, tokens.RefreshToken, tokens.ExpiresIn}))
 }
 
 // GetProfile retrieves user profile
 func (uc *UserController) GetProfile(c *gin.Context) {
+	logger := middleware.RequestLogger(c, uc.logger)
 	userID, exists := middleware.GetEntityID(c)
 	if !exists {
-		uc.logger.WithField("path", "/user/profile").Warn("User ID not found in context")
+		logger.WithField("path", "/user/profile").Warn("User ID not found in context")
 		c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.ErrUserIDNotFound, nil))
 		return
 	}
 
-	resp, err := uc.userClient.GetProfile(context.Background(), &User.GetProfileRequest{
+	ctx, cancel := uc.backendContext(c)
+	defer cancel()
+
+	resp, err := uc.userClient.GetProfile(ctx, &User.GetProfileRequest{
 		UserId: userID,
 	})
 
 	if err != nil {
-		uc.logger.WithFields(logrus.Fields{
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithFields(logrus.Fields{
 			"userId": userID,
 			"error":  err.Error(),
 		}).Error("Failed to retrieve profile")
@@ -333,32 +462,35 @@ func (uc *UserController) GetProfile(c *gin.Context) {
 		return
 	}
 
-	uc.logger.WithField("userId", userID).Info("Profile retrieved successfully")
-	c.JSON(http.StatusOK, model.SuccessResponse("Profile retrieved successfully", resp))
+	// Clients may select the profile fields they need with ?fields=
+	profile, err := utils.ProjectFields(resp, utils.ParseFields(c))
+	if err != nil {
+		logger.WithError(err).Error("Failed to project profile fields")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.ErrFailedRetrieveProfile, err))
+		return
+	}
+
+	logger.WithField("userId", userID).Info("Profile retrieved successfully")
+	c.JSON(http.StatusOK, model.SuccessResponse("Profile retrieved successfully", profile))
 }
 
 // UpdateProfile handles profile updates
 func (uc *UserController) UpdateProfile(c *gin.Context) {
-	var request model.UpdateProfileRequest
-
-	if err := c.ShouldBindJSON(&request); err != nil {
-		uc.logger.WithFields(logrus.Fields{
-			"error": err.Error(),
-			"path":  "/user/profile/update",
-		}).Error("Failed to bind update profile request")
-		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrInvalidRequestFormat, err))
+	logger := middleware.RequestLogger(c, uc.logger)
+	request, ok := bindJSON[model.UpdateProfileRequest](c, uc.logger)
+	if !ok {
 		return
 	}
 
 	userID, exists := middleware.GetEntityID(c)
 	if !exists {
-		uc.logger.WithField("path", "/user/profile/update").Warn("User ID not found in context")
+		logger.WithField("path", "/user/profile/update").Warn("User ID not found in context")
 		c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.ErrUserIDNotFound, nil))
 		return
 	}
 
 	if !uc.validateName(request.Name) {
-		uc.logger.WithFields(logrus.Fields{
+		logger.WithFields(logrus.Fields{
 			"userId": userID,
 			"name":   request.Name,
 		}).Warn("Invalid name format")
@@ -366,8 +498,9 @@ func (uc *UserController) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	if !uc.validatePhone(request.PhoneNumber) {
-		uc.logger.WithFields(logrus.Fields{
+	phoneNumber, err := request.PhoneNumber.Uint64()
+	if err != nil {
+		logger.WithFields(logrus.Fields{
 			"userId":      userID,
 			"phoneNumber": request.PhoneNumber,
 		}).Warn("Invalid phone number format")
@@ -375,14 +508,20 @@ func (uc *UserController) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	resp, err := uc.userClient.UpdateProfile(context.Background(), &User.UpdateProfileRequest{
+	ctx, cancel := uc.backendContext(c)
+	defer cancel()
+
+	resp, err := uc.userClient.UpdateProfile(ctx, &User.UpdateProfileRequest{
 		UserId:      userID,
 		Name:        request.Name,
-		PhoneNumber: request.PhoneNumber,
+		PhoneNumber: phoneNumber,
 	})
 
 	if err != nil {
-		uc.logger.WithFields(logrus.Fields{
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithFields(logrus.Fields{
 			"userId": userID,
 			"error":  err.Error(),
 		}).Error("Failed to update profile")
@@ -390,37 +529,45 @@ func (uc *UserController) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	uc.logger.WithField("userId", userID).Info("Profile updated successfully")
+	logger.WithField("userId", userID).Info("Profile updated successfully")
 	c.JSON(http.StatusOK, model.SuccessResponse("Profile updated successfully", resp))
 }
 
 // VerifyEmail handles email verification
 func (uc *UserController) VerifyEmail(c *gin.Context) {
-	var request model.VerifyEmailRequest
+	logger := middleware.RequestLogger(c, uc.logger)
+	request, ok := bindJSON[model.VerifyEmailRequest](c, uc.logger)
+	if !ok {
+		return
+	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		uc.logger.WithFields(logrus.Fields{
-			"error": err.Error(),
-			"path":  "/user/email/verify",
-		}).Error("Failed to bind verify email request")
-		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrInvalidRequestFormat, err))
+	request.VerificationCode = strings.TrimSpace(request.VerificationCode)
+	if err := uc.validateVerificationCode(request.VerificationCode); err != nil {
+		logger.WithField("path", "/user/email/verify").Warn("Invalid verification code format")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrInvalidVerificationCode, err))
 		return
 	}
 
 	userID, exists := middleware.GetEntityID(c)
 	if !exists {
-		uc.logger.WithField("path", "/user/email/verify").Warn("User ID not found in context")
+		logger.WithField("path", "/user/email/verify").Warn("User ID not found in context")
 		c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.ErrUserIDNotFound, nil))
 		return
 	}
 
-	resp, err := uc.userClient.VerifyEmail(context.Background(), &User.EmailVerificationRequest{
+	ctx, cancel := uc.backendContext(c)
+	defer cancel()
+
+	resp, err := uc.userClient.VerifyEmail(ctx, &User.EmailVerificationRequest{
 		UserId:           userID,
 		VerificationCode: request.VerificationCode,
 	})
 
 	if err != nil {
-		uc.logger.WithFields(logrus.Fields{
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithFields(logrus.Fields{
 			"userId": userID,
 			"error":  err.Error(),
 		}).Error("Failed to verify email")
@@ -428,61 +575,86 @@ func (uc *UserController) VerifyEmail(c *gin.Context) {
 		return
 	}
 
-	uc.logger.WithField("userId", userID).Info("Email verified successfully")
+	logger.WithField("userId", userID).Info("Email verified successfully")
 	c.JSON(http.StatusOK, model.SuccessResponse("Email verified successfully", resp))
 }
 
-// GetUserByToken retrieves user information using token
+// GetUserByToken returns the user identified by the request's token. The route sits
+// behind JWTAuthMiddleware, so the token has already been validated and the user is
+// looked up from the identity in its claims rather than by sending the raw token to
+// the User service.
 func (uc *UserController) GetUserByToken(c *gin.Context) {
-	token := c.GetHeader("Authorization")
-	if token == "" {
-		uc.logger.WithField("path", "/user/token").Warn("Authorization token is missing")
-		c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.ErrAuthorizationTokenRequired, nil))
+	logger := middleware.RequestLogger(c, uc.logger)
+	userID, exists := middleware.GetEntityID(c)
+	if !exists {
+		logger.WithField("path", "/api/users/me").Warn("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.ErrUserIDNotFound, nil))
 		return
 	}
 
-	token = strings.TrimPrefix(token, "Bearer ")
+	ctx, cancel := uc.backendContext(c)
+	defer cancel()
 
-	resp, err := uc.userClient.GetUserByToken(context.Background(), &User.GetUserByTokenRequest{
-		Token: token,
+	resp, err := uc.userClient.GetProfile(ctx, &User.GetProfileRequest{
+		UserId: userID,
 	})
 
 	if err != nil {
-		uc.logger.WithFields(logrus.Fields{
-			"token": token,
-			"error": err.Error(),
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithFields(logrus.Fields{
+			"userId": userID,
+			"error":  err.Error(),
 		}).Error("Failed to retrieve user by token")
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.ErrFailedRetrieveUser, err))
 		return
 	}
 
-	uc.logger.WithField("token", token).Info("User retrieved successfully by token")
+	logger.WithField("userId", userID).Info("User retrieved successfully by token")
 	c.JSON(http.StatusOK, model.SuccessResponse("User retrieved successfully", resp))
 }
 
 // Address Management
 
-func (uc *UserController) AddAddress(c *gin.Context) {
-	var request model.AddAddressRequest
+// ValidateAddress checks an address with the same rules as adding one, without
+// saving it. Unless ?normalize=false is given, the address is normalized first and
+// the normalized form is returned for the user to confirm.
+func (uc *UserController) ValidateAddress(c *gin.Context) {
+	request, ok := bindJSON[model.ValidateAddressRequest](c, uc.logger)
+	if !ok {
+		return
+	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		uc.logger.WithFields(logrus.Fields{
-			"error": err.Error(),
-			"path":  "/user/address/add",
-		}).Error("Failed to bind add address request")
-		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrInvalidRequestFormat, err))
+	address := model.Address(request.Address)
+	if c.Query("normalize") != "false" {
+		address = normalizeAddress(address)
+	}
+
+	problems := uc.addressProblems(address)
+	c.JSON(http.StatusOK, model.SuccessResponse(model.MsgAddressChecked, model.AddressValidation{
+		Valid:   len(problems) == 0,
+		Address: address,
+		Errors:  problems,
+	}))
+}
+
+func (uc *UserController) AddAddress(c *gin.Context) {
+	logger := middleware.RequestLogger(c, uc.logger)
+	request, ok := bindJSON[model.AddAddressRequest](c, uc.logger)
+	if !ok {
 		return
 	}
 
 	userID, exists := middleware.GetEntityID(c)
 	if !exists {
-		uc.logger.WithField("path", "/user/address/add").Warn("User ID not found in context")
+		logger.WithField("path", "/user/address/add").Warn("User ID not found in context")
 		c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.ErrUserIDNotFound, nil))
 		return
 	}
 
 	if err := uc.validateAddress(request.Address); err != nil {
-		uc.logger.WithFields(logrus.Fields{
+		logger.WithFields(logrus.Fields{
 			"userId":  userID,
 			"address": request.Address,
 			"error":   err.Error(),
@@ -491,18 +663,19 @@ func (uc *UserController) AddAddress(c *gin.Context) {
 		return
 	}
 
-	resp, err := uc.userClient.AddAddress(context.Background(), &User.AddAddressRequest{
-		UserId: userID,
-		Address: &User.Address{
-			StreetName: request.Address.StreetName,
-			Locality:   request.Address.Locality,
-			State:      request.Address.State,
-			Pincode:    request.Address.Pincode,
-		},
+	ctx, cancel := uc.backendContext(c)
+	defer cancel()
+
+	resp, err := uc.userClient.AddAddress(ctx, &User.AddAddressRequest{
+		UserId:  userID,
+		Address: toUserAddress(request.Address),
 	})
 
 	if err != nil {
-		uc.logger.WithFields(logrus.Fields{
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithFields(logrus.Fields{
 			"userId": userID,
 			"error":  err.Error(),
 		}).Error("Failed to add address")
@@ -510,24 +683,33 @@ func (uc *UserController) AddAddress(c *gin.Context) {
 		return
 	}
 
-	uc.logger.WithField("userId", userID).Info("Address added successfully")
-	c.JSON(http.StatusOK, model.SuccessResponse("Address added successfully", resp))
+	logger.WithField("userId", userID).Info("Address added successfully")
+	// Addresses have no single-resource endpoint, so Location points at the user's address list
+	c.Header("Location", "/api/users/address/list")
+	c.JSON(http.StatusCreated, model.SuccessResponse("Address added successfully", resp))
 }
 
 func (uc *UserController) GetAddresses(c *gin.Context) {
+	logger := middleware.RequestLogger(c, uc.logger)
 	userID, exists := middleware.GetEntityID(c)
 	if !exists {
-		uc.logger.WithField("path", "/user/addresses").Warn("User ID not found in context")
+		logger.WithField("path", "/user/addresses").Warn("User ID not found in context")
 		c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.ErrUserIDNotFound, nil))
 		return
 	}
 
-	resp, err := uc.userClient.GetAddresses(context.Background(), &User.GetAddressesRequest{
+	ctx, cancel := uc.backendContext(c)
+	defer cancel()
+
+	resp, err := uc.userClient.GetAddresses(ctx, &User.GetAddressesRequest{
 		UserId: userID,
 	})
 
 	if err != nil {
-		uc.logger.WithFields(logrus.Fields{
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithFields(logrus.Fields{
 			"userId": userID,
 			"error":  err.Error(),
 		}).Error("Failed to retrieve addresses")
@@ -535,37 +717,33 @@ func (uc *UserController) GetAddresses(c *gin.Context) {
 		return
 	}
 
-	uc.logger.WithField("userId", userID).Info("Addresses retrieved successfully")
+	logger.WithField("userId", userID).Info("Addresses retrieved successfully")
 	c.JSON(http.StatusOK, model.SuccessResponse("Addresses retrieved successfully", resp))
 }
 
 func (uc *UserController) EditAddress(c *gin.Context) {
+	logger := middleware.RequestLogger(c, uc.logger)
 	addressID := c.Query("addressId")
 	if addressID == "" {
-		uc.logger.WithField("path", "/user/address/edit").Warn("Address ID is missing")
+		logger.WithField("path", "/user/address/edit").Warn("Address ID is missing")
 		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrAddressIDRequired, nil))
 		return
 	}
 
-	var request model.EditAddressRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		uc.logger.WithFields(logrus.Fields{
-			"error": err.Error(),
-			"path":  "/user/address/edit",
-		}).Error("Failed to bind edit address request")
-		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrInvalidRequestFormat, err))
+	request, ok := bindJSON[model.EditAddressRequest](c, uc.logger)
+	if !ok {
 		return
 	}
 
 	userID, exists := middleware.GetEntityID(c)
 	if !exists {
-		uc.logger.WithField("path", "/user/address/edit").Warn("User ID not found in context")
+		logger.WithField("path", "/user/address/edit").Warn("User ID not found in context")
 		c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.ErrUserIDNotFound, nil))
 		return
 	}
 
 	if err := uc.validateAddress(request.Address); err != nil {
-		uc.logger.WithFields(logrus.Fields{
+		logger.WithFields(logrus.Fields{
 			"userId":  userID,
 			"address": request.Address,
 			"error":   err.Error(),
@@ -574,19 +752,20 @@ func (uc *UserController) EditAddress(c *gin.Context) {
 		return
 	}
 
-	resp, err := uc.userClient.EditAddress(context.Background(), &User.EditAddressRequest{
+	ctx, cancel := uc.backendContext(c)
+	defer cancel()
+
+	resp, err := uc.userClient.EditAddress(ctx, &User.EditAddressRequest{
 		UserId:    userID,
 		AddressId: addressID,
-		Address: &User.Address{
-			StreetName: request.Address.StreetName,
-			Locality:   request.Address.Locality,
-			State:      request.Address.State,
-			Pincode:    request.Address.Pincode,
-		},
+		Address:   toUserAddress(request.Address),
 	})
 
 	if err != nil {
-		uc.logger.WithFields(logrus.Fields{
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithFields(logrus.Fields{
 			"userId": userID,
 			"error":  err.Error(),
 		}).Error("Failed to edit address")
@@ -594,32 +773,39 @@ func (uc *UserController) EditAddress(c *gin.Context) {
 		return
 	}
 
-	uc.logger.WithField("userId", userID).Info("Address updated successfully")
+	logger.WithField("userId", userID).Info("Address updated successfully")
 	c.JSON(http.StatusOK, model.SuccessResponse("Address updated successfully", resp))
 }
 
 func (uc *UserController) DeleteAddress(c *gin.Context) {
+	logger := middleware.RequestLogger(c, uc.logger)
 	addressID := c.Param("addressId")
 	if addressID == "" {
-		uc.logger.WithField("path", "/user/address/delete").Warn("Address ID is missing")
+		logger.WithField("path", "/user/address/delete").Warn("Address ID is missing")
 		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrAddressIDRequired, nil))
 		return
 	}
 
 	userID, exists := middleware.GetEntityID(c)
 	if !exists {
-		uc.logger.WithField("path", "/user/address/delete").Warn("User ID not found in context")
+		logger.WithField("path", "/user/address/delete").Warn("User ID not found in context")
 		c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.ErrUserIDNotFound, nil))
 		return
 	}
 
-	resp, err := uc.userClient.DeleteAddress(context.Background(), &User.DeleteAddressRequest{
+	ctx, cancel := uc.backendContext(c)
+	defer cancel()
+
+	resp, err := uc.userClient.DeleteAddress(ctx, &User.DeleteAddressRequest{
 		UserId:    userID,
 		AddressId: addressID,
 	})
 
 	if err != nil {
-		uc.logger.WithFields(logrus.Fields{
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithFields(logrus.Fields{
 			"userId": userID,
 			"error":  err.Error(),
 		}).Error("Failed to delete address")
@@ -627,26 +813,33 @@ func (uc *UserController) DeleteAddress(c *gin.Context) {
 		return
 	}
 
-	uc.logger.WithField("userId", userID).Info("Address deleted successfully")
+	logger.WithField("userId", userID).Info("Address deleted successfully")
 	c.JSON(http.StatusOK, model.SuccessResponse("Address deleted successfully", resp))
 }
 
 // Admin Functions
 
 func (uc *UserController) BanUser(c *gin.Context) {
+	logger := middleware.RequestLogger(c, uc.logger)
 	targetUserID := c.Query("userId")
 	if targetUserID == "" {
-		uc.logger.WithField("path", "/admin/user/ban").Warn("Target user ID is missing")
+		logger.WithField("path", "/admin/user/ban").Warn("Target user ID is missing")
 		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrUserIDRequired, nil))
 		return
 	}
 
-	resp, err := uc.userClient.BanUser(context.Background(), &User.BanUserRequest{
+	ctx, cancel := uc.backendContext(c)
+	defer cancel()
+
+	resp, err := uc.userClient.BanUser(ctx, &User.BanUserRequest{
 		UserId: targetUserID,
 	})
 
 	if err != nil {
-		uc.logger.WithFields(logrus.Fields{
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithFields(logrus.Fields{
 			"userId": targetUserID,
 			"error":  err.Error(),
 		}).Error("Failed to ban user")
@@ -654,26 +847,37 @@ func (uc *UserController) BanUser(c *gin.Context) {
 		return
 	}
 
-	uc.logger.WithFields(logrus.Fields{
-		"userId": targetUserID,
+	adminID, _ := middleware.GetEntityID(c)
+	logger.WithFields(logrus.Fields{
+		"audit":   true,
+		"action":  "ban",
+		"adminId": adminID,
+		"userId":  targetUserID,
 	}).Info("User banned successfully")
 	c.JSON(http.StatusOK, model.SuccessResponse("User banned successfully", resp))
 }
 
 func (uc *UserController) UnBanUser(c *gin.Context) {
+	logger := middleware.RequestLogger(c, uc.logger)
 	targetUserID := c.Query("userId")
 	if targetUserID == "" {
-		uc.logger.WithField("path", "/admin/user/unban").Warn("Target user ID is missing")
+		logger.WithField("path", "/admin/user/unban").Warn("Target user ID is missing")
 		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrUserIDRequired, nil))
 		return
 	}
 
-	resp, err := uc.userClient.UnBanUser(context.Background(), &User.UnBanUserRequest{
+	ctx, cancel := uc.backendContext(c)
+	defer cancel()
+
+	resp, err := uc.userClient.UnBanUser(ctx, &User.UnBanUserRequest{
 		UserId: targetUserID,
 	})
 
 	if err != nil {
-		uc.logger.WithFields(logrus.Fields{
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithFields(logrus.Fields{
 			"userId": targetUserID,
 			"error":  err.Error(),
 		}).Error("Failed to unban user")
@@ -681,26 +885,37 @@ func (uc *UserController) UnBanUser(c *gin.Context) {
 		return
 	}
 
-	uc.logger.WithFields(logrus.Fields{
-		"userId": targetUserID,
+	adminID, _ := middleware.GetEntityID(c)
+	logger.WithFields(logrus.Fields{
+		"audit":   true,
+		"action":  "unban",
+		"adminId": adminID,
+		"userId":  targetUserID,
 	}).Info("User unbanned successfully")
 	c.JSON(http.StatusOK, model.SuccessResponse("User unbanned successfully", resp))
 }
 
 func (uc *UserController) CheckBan(c *gin.Context) {
+	logger := middleware.RequestLogger(c, uc.logger)
 	targetUserID := c.Query("userId")
 	if targetUserID == "" {
-		uc.logger.WithField("path", "/admin/user/checkban").Warn("Target user ID is missing")
+		logger.WithField("path", "/admin/user/checkban").Warn("Target user ID is missing")
 		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrUserIDRequired, nil))
 		return
 	}
 
-	resp, err := uc.userClient.CheckBan(context.Background(), &User.CheckBanRequest{
+	ctx, cancel := uc.backendContext(c)
+	defer cancel()
+
+	resp, err := uc.userClient.CheckBan(ctx, &User.CheckBanRequest{
 		UserId: targetUserID,
 	})
 
 	if err != nil {
-		uc.logger.WithFields(logrus.Fields{
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithFields(logrus.Fields{
 			"userId": targetUserID,
 			"error":  err.Error(),
 		}).Error("Failed to check ban status")
@@ -708,24 +923,31 @@ func (uc *UserController) CheckBan(c *gin.Context) {
 		return
 	}
 
-	uc.logger.WithFields(logrus.Fields{
+	logger.WithFields(logrus.Fields{
 		"userId": targetUserID,
 	}).Info("Ban status checked successfully")
 	c.JSON(http.StatusOK, model.SuccessResponse("Ban status checked successfully", resp))
 }
 
 func (uc *UserController) GetAllUsers(c *gin.Context) {
-	resp, err := uc.userClient.GetAllUsers(context.Background(), &User.GetAllUsersRequest{})
+	logger := middleware.RequestLogger(c, uc.logger)
+	ctx, cancel := uc.backendContext(c)
+	defer cancel()
+
+	resp, err := uc.userClient.GetAllUsers(ctx, &User.GetAllUsersRequest{})
 
 	if err != nil {
-		uc.logger.WithFields(logrus.Fields{
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithFields(logrus.Fields{
 			"error": err.Error(),
 		}).Error("Failed to retrieve all users")
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.ErrFailedRetrieveUsers, err))
 		return
 	}
 
-	uc.logger.WithField("count", len(resp.Users)).Info("All users retrieved successfully")
+	logger.WithField("count", len(resp.Users)).Info("All users retrieved successfully")
 	c.JSON(http.StatusOK, model.SuccessResponse("Users retrieved successfully", resp))
 }
 
@@ -733,3 +955,109 @@ func (uc *UserController) GetAllUsers(c *gin.Context) {
 func (uc *UserController) GetUserClient() User.UserServiceClient {
 	return uc.userClient
 }
+
+// ImpersonateUser lets an admin act as a user for support. The issued token is
+// short-lived and read-only, and every impersonation is logged for audit.
+func (uc *UserController) ImpersonateUser(c *gin.Context) {
+	logger := middleware.RequestLogger(c, uc.logger)
+	targetUserID := c.Param("userId")
+	if targetUserID == "" {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.ErrUserIDRequired, nil))
+		return
+	}
+	adminID, _ := middleware.GetEntityID(c)
+
+	ctx, cancel := uc.backendContext(c)
+	defer cancel()
+
+	profile, err := uc.userClient.GetProfile(ctx, &User.GetProfileRequest{
+		UserId: targetUserID,
+	})
+	if err != nil {
+		if abortIfUnanswered(c, err) {
+			return
+		}
+		logger.WithFields(logrus.Fields{
+			"userId": targetUserID,
+			"error":  err.Error(),
+		}).Error("Failed to look up user to impersonate")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.ErrFailedImpersonate, err))
+		return
+	}
+
+	token, err := generateTokenWithRetry(func(ID string) (string, error) {
+		return uc.tokens.GenerateImpersonation(ID, adminID, uc.impersonationTokenTTL)
+	}, profile.UserId, logger)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.ErrFailedGenerateToken, err))
+		return
+	}
+
+	logger.WithFields(logrus.Fields{
+		"audit":     true,
+		"adminId":   adminID,
+		"userId":    profile.UserId,
+		"expiresIn": uc.impersonationTokenTTL.String(),
+	}).Warn("Admin impersonating user")
+
+	c.JSON(http.StatusOK, model.SuccessResponse(model.MsgImpersonating, gin.H{
+		"token":     token,
+		"userId":    profile.UserId,
+		"readOnly":  true,
+		"expiresAt": time.Now().Add(uc.impersonationTokenTTL).Unix(),
+	}))
+}
+
+// BulkBanUsers bans every listed user, reporting the outcome per user
+func (uc *UserController) BulkBanUsers(c *gin.Context) {
+	uc.bulkModerate(c, "ban", func(ctx context.Context, userID string) error {
+		_, err := uc.userClient.BanUser(ctx, &User.BanUserRequest{UserId: userID})
+		return err
+	})
+}
+
+// BulkUnbanUsers unbans every listed user, reporting the outcome per user
+func (uc *UserController) BulkUnbanUsers(c *gin.Context) {
+	uc.bulkModerate(c, "unban", func(ctx context.Context, userID string) error {
+		_, err := uc.userClient.UnBanUser(ctx, &User.UnBanUserRequest{UserId: userID})
+		return err
+	})
+}
+
+// bulkModerate applies action to each user concurrently with a bounded pool and logs
+// every outcome for audit. The user service does not store a reason, so it is only
+// recorded in the audit log.
+func (uc *UserController) bulkModerate(c *gin.Context, actionName string, action func(ctx context.Context, userID string) error) {
+	logger := middleware.RequestLogger(c, uc.logger)
+	request, ok := bindJSON[model.BulkModerationRequest](c, uc.logger)
+	if !ok {
+		return
+	}
+	adminID, _ := middleware.GetEntityID(c)
+
+	ctx, cancel := uc.backendContext(c)
+	defer cancel()
+
+	results := make([]model.BulkModerationResult, len(request.UserIDs))
+	utils.FanOut(len(request.UserIDs), uc.fanOutLimit, func(i int) {
+		userID := request.UserIDs[i]
+		results[i] = model.BulkModerationResult{UserID: userID, Success: true}
+
+		entry := logger.WithFields(logrus.Fields{
+			"audit":   true,
+			"action":  actionName,
+			"adminId": adminID,
+			"userId":  userID,
+			"reason":  request.Reason,
+		})
+		if err := action(ctx, userID); err != nil {
+			results[i].Success = false
+			results[i].Error = err.Error()
+			entry.WithError(err).Error("Bulk moderation failed for user")
+			return
+		}
+		entry.Info("Bulk moderation applied to user")
+	})
+
+	c.JSON(http.StatusOK, model.SuccessResponse(model.MsgBulkModeration, results))
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	User "github.com/liju-github/CentralisedFoodbuddyMicroserviceProto/User"
+	config "github.com/liju-github/FoodBuddyAPIGateway/configs"
+	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
+	"google.golang.org/grpc"
+)
+
+// fakeProfileClient returns a profile for any user and records who was asked for
+type fakeProfileClient struct {
+	User.UserServiceClient
+	userID string
+}
+
+func (f *fakeProfileClient) GetProfile(_ context.Context, req *User.GetProfileRequest, _ ...grpc.CallOption) (*User.GetProfileResponse, error) {
+	f.userID = req.UserId
+	return &User.GetProfileResponse{UserId: req.UserId, Name: "Test User"}, nil
+}
+
+func TestGetUserByToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWTSECRET", "test-secret")
+	t.Setenv("JWTKEYS", "")
+	t.Setenv("JWTSIGNINGKEYID", "")
+	t.Setenv("MAXSESSIONLIFETIME", "30m")
+
+	sign := func(t *testing.T, secret string, claims *middleware.Claims) string {
+		keys, err := middleware.NewKeyRing("", nil, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		token, err := keys.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	claims := func(expiresIn time.Duration) *middleware.Claims {
+		return &middleware.Claims{
+			ID:               "user1",
+			Role:             middleware.RoleUser,
+			TokenType:        middleware.TokenTypeAccess,
+			SessionStart:     time.Now().Unix(),
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn))},
+		}
+	}
+
+	staleSession := claims(time.Hour)
+	staleSession.SessionStart = time.Now().Add(-time.Hour).Unix()
+
+	tests := []struct {
+		name       string
+		token      string
+		wantCode   int
+		wantUserID string
+	}{
+		{name: "valid token", token: sign(t, "test-secret", claims(time.Hour)), wantCode: http.StatusOK, wantUserID: "user1"},
+		{name: "missing token", wantCode: http.StatusUnauthorized},
+		{name: "token signed with another secret", token: sign(t, "other-secret", claims(time.Hour)), wantCode: http.StatusUnauthorized},
+		{name: "expired token", token: sign(t, "test-secret", claims(-time.Minute)), wantCode: http.StatusUnauthorized},
+		{name: "token of an expired session", token: sign(t, "test-secret", staleSession), wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &fakeProfileClient{}
+			uc := NewUserController(config.LoadConfig(), backend, nil, nil)
+
+			router := gin.New()
+			router.GET("/api/users/me", middleware.JWTAuthMiddleware(), middleware.UserAuthMiddleware(), uc.GetUserByToken)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/users/me", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("got %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+			if backend.userID != tt.wantUserID {
+				t.Errorf("backend asked for user %q, want %q", backend.userID, tt.wantUserID)
+			}
+		})
+	}
+
+	t.Run("no user in context", func(t *testing.T) {
+		router := gin.New()
+		router.GET("/api/users/me", NewUserController(config.LoadConfig(), &fakeProfileClient{}, nil, nil).GetUserByToken)
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/users/me", nil))
+		if recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("got %d, want 401", recorder.Code)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+)
+
+// TokenBlacklist records revoked tokens by their jti claim. An entry only needs
+// to be kept until the token would have expired anyway.
+type TokenBlacklist interface {
+	Revoke(jti string, expiresAt time.Time)
+	IsRevoked(jti string) bool
+	// RevokeIfNew revokes the token and reports whether it was not revoked already,
+	// as one atomic step
+	RevokeIfNew(jti string, expiresAt time.Time) bool
+}
+
+// InMemoryTokenBlacklist keeps revoked token IDs in process memory. It is safe for
+// concurrent use.
+type InMemoryTokenBlacklist struct {
+	mutex   sync.RWMutex
+	revoked map[string]time.Time
+}
+
+// NewInMemoryTokenBlacklist creates an empty blacklist that drops the entries of
+// expired tokens every cleanupInterval
+func NewInMemoryTokenBlacklist(cleanupInterval time.Duration) *InMemoryTokenBlacklist {
+	blacklist := &InMemoryTokenBlacklist{revoked: make(map[string]time.Time)}
+
+	// Background cleanup for expired tokens
+	go func() {
+		ticker := time.NewTicker(cleanupInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			now := time.Now()
+			blacklist.mutex.Lock()
+			for jti, expiresAt := range blacklist.revoked {
+				if now.After(expiresAt) {
+					delete(blacklist.revoked, jti)
+				}
+			}
+			blacklist.mutex.Unlock()
+		}
+	}()
+
+	return blacklist
+}
+
+// Revoke rejects the token until expiresAt
+func (b *InMemoryTokenBlacklist) Revoke(jti string, expiresAt time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.revoked[jti] = expiresAt
+}
+
+// RevokeIfNew revokes the token until expiresAt, reporting false if it already was
+func (b *InMemoryTokenBlacklist) RevokeIfNew(jti string, expiresAt time.Time) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, revoked := b.revoked[jti]; revoked {
+		return false
+	}
+	b.revoked[jti] = expiresAt
+	return true
+}
+
+// IsRevoked reports whether the token was revoked
+func (b *InMemoryTokenBlacklist) IsRevoked(jti string) bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	_, revoked := b.revoked[jti]
+	return revoked
+}
+
+// revokedTokens is consulted by JWTAuthMiddleware and when refreshing. It is set
+// once at startup, before any request is served.
+var revokedTokens TokenBlacklist
+
+// UseTokenBlacklist sets the blacklist revoked tokens are recorded in. Until it is
+// called tokens cannot be revoked.
+func UseTokenBlacklist(blacklist TokenBlacklist) {
+	revokedTokens = blacklist
+}
+
+// RevokeToken rejects the token with the given jti until expiresAt. Tokens without
+// a jti, issued before revocation existed, cannot be revoked.
+func RevokeToken(jti string, expiresAt time.Time) {
+	if revokedTokens != nil && jti != "" {
+		revokedTokens.Revoke(jti, expiresAt)
+	}
+}
+
+// ConsumeToken revokes a single-use token and reports whether this was its first use.
+// Concurrent calls for the same jti succeed at most once. Tokens without a jti cannot
+// be tracked and always succeed, as they do when no blacklist is set.
+func ConsumeToken(jti string, expiresAt time.Time) bool {
+	if revokedTokens == nil || jti == "" {
+		return true
+	}
+	return revokedTokens.RevokeIfNew(jti, expiresAt)
+}
+
+// tokenRevoked reports whether the token with the given jti was revoked
+func tokenRevoked(jti string) bool {
+	return revokedTokens != nil && jti != "" && revokedTokens.IsRevoked(jti)
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRevokeIfNewSucceedsOnce(t *testing.T) {
+	blacklist := NewInMemoryTokenBlacklist(time.Hour)
+	expiresAt := time.Now().Add(time.Hour)
+
+	var wins atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if blacklist.RevokeIfNew("refresh-1", expiresAt) {
+				wins.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := wins.Load(); got != 1 {
+		t.Fatalf("%d concurrent exchanges succeeded, want 1", got)
+	}
+	if !blacklist.IsRevoked("refresh-1") {
+		t.Fatal("token not revoked after use")
+	}
+}
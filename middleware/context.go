@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/metadata"
+)
+
+// WithRequestTimeout returns a context for backend calls made on behalf of c, bounded
+// by timeout. It is rooted at the gin request, so the call is cancelled when the
+// client disconnects.
+func WithRequestTimeout(c *gin.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.Request.Context(), timeout)
+}
+
+// DetachedContext returns a context for backend calls that outlive the request made
+// to c, such as background notifications. It is not cancelled with the request but
+// carries the request's outgoing gRPC metadata, so the calls keep their tenant.
+// Must be called before the handler returns.
+func DetachedContext(c *gin.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx := context.Background()
+	if md, ok := metadata.FromOutgoingContext(c.Request.Context()); ok {
+		ctx = metadata.NewOutgoingContext(ctx, md.Copy())
+	}
+	return context.WithTimeout(ctx, timeout)
+}
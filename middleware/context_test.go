@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestDetachedContextKeepsTenant(t *testing.T) {
+	requestCtx, cancelRequest := context.WithCancel(context.Background())
+	requestCtx = metadata.AppendToOutgoingContext(requestCtx, TenantMetadataKey, "brand-a")
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/", nil).WithContext(requestCtx)
+
+	ctx, cancel := DetachedContext(c, time.Minute)
+	defer cancel()
+	cancelRequest()
+
+	if err := ctx.Err(); err != nil {
+		t.Fatalf("detached context ended with the request: %v", err)
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if got := md.Get(TenantMetadataKey); len(got) != 1 || got[0] != "brand-a" {
+		t.Fatalf("tenant metadata %v, want [brand-a]", got)
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// DeprecationMiddleware marks the routes it wraps as deprecated. Responses carry
+// "Deprecation: true" and a Sunset header with the removal date, and every call is
+// logged with the caller's identity so remaining clients can be chased before then.
+// sunsetDate is YYYY-MM-DD and is sent as an HTTP date; other values are sent as is.
+func DeprecationMiddleware(sunsetDate string) gin.HandlerFunc {
+	sunset := sunsetDate
+	if date, err := time.Parse(time.DateOnly, sunsetDate); err == nil {
+		sunset = date.UTC().Format(http.TimeFormat)
+	}
+
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Sunset", sunset)
+
+		c.Next()
+
+		// Logged afterwards so the identity set by JWTAuthMiddleware is included
+		role, _ := GetEntityRole(c)
+		RequestLogger(c, logrus.StandardLogger()).WithFields(logrus.Fields{
+			"deprecated": true,
+			"route":      c.FullPath(),
+			"sunset":     sunsetDate,
+			"role":       role,
+			"clientIp":   c.ClientIP(),
+			"userAgent":  c.Request.UserAgent(),
+		}).Warn("Deprecated route called")
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestDeprecationMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.StandardLogger()
+	hooks := logger.ReplaceHooks(make(logrus.LevelHooks))
+	t.Cleanup(func() { logger.ReplaceHooks(hooks) })
+	hook := test.NewLocal(logger)
+
+	router := gin.New()
+	router.GET("/old", func(c *gin.Context) {
+		c.Set(EntityID, "user1")
+		c.Set(RoleKey, RoleUser)
+		c.Next()
+	}, DeprecationMiddleware("2027-04-30"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/old", nil))
+
+	if got := recorder.Header().Get("Deprecation"); got != "true" {
+		t.Errorf("Deprecation header %q, want true", got)
+	}
+	if got := recorder.Header().Get("Sunset"); got != "Fri, 30 Apr 2027 00:00:00 GMT" {
+		t.Errorf("Sunset header %q", got)
+	}
+
+	entry := hook.LastEntry()
+	if entry == nil || entry.Message != "Deprecated route called" {
+		t.Fatalf("no deprecated route log entry: %v", hook.AllEntries())
+	}
+	for field, want := range map[string]interface{}{"route": "/old", "sunset": "2027-04-30", "role": RoleUser, "entityId": "user1"} {
+		if got := entry.Data[field]; got != want {
+			t.Errorf("log field %s = %v, want %v", field, got, want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientVersionHeader carries the version of the calling client app
+const ClientVersionHeader = "X-Client-Version"
+
+// ClientPlatformHeader carries the platform of the calling client app: ios, android or web
+const ClientPlatformHeader = "X-Client-Platform"
+
+// ClientVersionMiddleware rejects clients older than the minimum version of their
+// platform with 426 Upgrade Required. The platform is read from ClientPlatformHeader
+// and looked up in platformMinimums; unknown or absent platforms use defaultMinimum.
+// Requests are let through when no minimum applies to them.
+func ClientVersionMiddleware(defaultMinimum string, platformMinimums map[string]string) gin.HandlerFunc {
+	minimums := make(map[string]string, len(platformMinimums))
+	for platform, version := range platformMinimums {
+		minimums[strings.ToLower(platform)] = version
+	}
+
+	return func(c *gin.Context) {
+		platform := strings.ToLower(strings.TrimSpace(c.GetHeader(ClientPlatformHeader)))
+		minimum, ok := minimums[platform]
+		if !ok {
+			minimum = defaultMinimum
+		}
+		if minimum == "" {
+			c.Next()
+			return
+		}
+
+		// Clients that predate the header are older than any configured minimum
+		version := strings.TrimSpace(c.GetHeader(ClientVersionHeader))
+		cmp := -1
+		if version != "" {
+			var err error
+			cmp, err = compareVersions(version, minimum)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"message": fmt.Sprintf("%s header has an invalid version format", ClientVersionHeader),
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		if cmp < 0 {
+			c.JSON(http.StatusUpgradeRequired, gin.H{
+				"success":        false,
+				"message":        "This version of the app is no longer supported, please update to continue",
+				"minimumVersion": minimum,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// compareVersions compares two semantic versions and returns -1, 0 or 1. Any number
+// of dotted numeric components is accepted, build metadata is ignored, and a
+// pre-release sorts before the release it precedes, so 2.0.0-beta < 2.0.0.
+func compareVersions(a, b string) (int, error) {
+	aCore, aPre := splitVersion(a)
+	bCore, bPre := splitVersion(b)
+	aParts := strings.Split(aCore, ".")
+	bParts := strings.Split(bCore, ".")
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		aNum, err := versionPart(aParts, i)
+		if err != nil {
+			return 0, err
+		}
+		bNum, err := versionPart(bParts, i)
+		if err != nil {
+			return 0, err
+		}
+
+		if aNum < bNum {
+			return -1, nil
+		}
+		if aNum > bNum {
+			return 1, nil
+		}
+	}
+
+	return comparePreReleases(aPre, bPre), nil
+}
+
+// splitVersion strips a leading "v" and any "+build" suffix and splits the version
+// into its numeric core and pre-release
+func splitVersion(version string) (string, string) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	version, _, _ = strings.Cut(version, "+")
+	core, pre, _ := strings.Cut(version, "-")
+	return core, pre
+}
+
+// comparePreReleases orders pre-release labels of otherwise equal versions. No label
+// is a release and sorts last; numeric identifiers compare numerically and sort
+// before alphanumeric ones.
+func comparePreReleases(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	aIDs := strings.Split(a, ".")
+	bIDs := strings.Split(b, ".")
+	for i := 0; i < len(aIDs) && i < len(bIDs); i++ {
+		aNum, aErr := strconv.Atoi(aIDs[i])
+		bNum, bErr := strconv.Atoi(bIDs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNum != bNum {
+				if aNum < bNum {
+					return -1
+				}
+				return 1
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if cmp := strings.Compare(aIDs[i], bIDs[i]); cmp != 0 {
+				return cmp
+			}
+		}
+	}
+
+	switch {
+	case len(aIDs) < len(bIDs):
+		return -1
+	case len(aIDs) > len(bIDs):
+		return 1
+	}
+	return 0
+}
+
+// versionPart returns the numeric version component at index i, treating missing components as 0
+func versionPart(parts []string, i int) (int, error) {
+	if i >= len(parts) {
+		return 0, nil
+	}
+	return strconv.Atoi(parts[i])
+}
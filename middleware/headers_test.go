@@ -0,0 +1,55 @@
+package middleware
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		want    int
+		wantErr bool
+	}{
+		{a: "1.2.3", b: "1.2.3", want: 0},
+		{a: "1.10.0", b: "1.9.0", want: 1},
+		{a: "1.2.3", b: "1.3", want: -1},
+		{a: "v2.0.0", b: "2.0.0", want: 0},
+		{a: "1.0.0+build.5", b: "1.0.0", want: 0},
+
+		// Missing components count as 0
+		{a: "1.2", b: "1.2.0", want: 0},
+		{a: "2", b: "1.9.9", want: 1},
+		{a: "1", b: "1.0.1", want: -1},
+
+		// Pre-releases sort before their release and by their identifiers
+		{a: "2.0.0-beta", b: "2.0.0", want: -1},
+		{a: "2.0.0", b: "2.0.0-rc.1", want: 1},
+		{a: "2.0.0-alpha", b: "2.0.0-beta", want: -1},
+		{a: "2.0.0-beta.2", b: "2.0.0-beta.11", want: -1},
+		{a: "2.0.0-1", b: "2.0.0-alpha", want: -1},
+		{a: "2.0.0-beta", b: "2.0.0-beta.1", want: -1},
+		{a: "2.0.1-beta", b: "2.0.0", want: 1},
+
+		// Non-numeric core components are invalid
+		{a: "1.x.0", b: "1.0.0", wantErr: true},
+		{a: "1.0.0", b: "latest", wantErr: true},
+		{a: "1..0", b: "1.0.0", wantErr: true},
+		{a: "", b: "1.0.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			got, err := compareVersions(tt.a, tt.b)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %d, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
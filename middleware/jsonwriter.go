@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jsonBufferWriter holds back JSON response bodies so middleware can rewrite them
+// once the handler is done. Other bodies are written through, so streamed
+// downloads keep streaming.
+type jsonBufferWriter struct {
+	gin.ResponseWriter
+	buffer    bytes.Buffer
+	buffering bool
+	decided   bool
+}
+
+func (w *jsonBufferWriter) decide() {
+	if !w.decided {
+		w.decided = true
+		w.buffering = strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+	}
+}
+
+func (w *jsonBufferWriter) Write(data []byte) (int, error) {
+	w.decide()
+	if w.buffering {
+		return w.buffer.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *jsonBufferWriter) WriteString(s string) (int, error) {
+	w.decide()
+	if w.buffering {
+		return w.buffer.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Flush is a no-op while buffering, the body is sent when the handler returns
+func (w *jsonBufferWriter) Flush() {
+	if !w.buffering {
+		w.ResponseWriter.Flush()
+	}
+}
@@ -1,8 +1,6 @@
 package middleware
 
 import (
-	"context"
-	"errors"
 	"log"
 	"net/http"
 	"strings"
@@ -12,19 +10,77 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	User "github.com/liju-github/CentralisedFoodbuddyMicroserviceProto/User"
 	config "github.com/liju-github/FoodBuddyAPIGateway/configs"
+	"github.com/liju-github/FoodBuddyAPIGateway/model"
 )
 
 // Custom claims structure
 type Claims struct {
 	ID   string `json:"id"`
 	Role string `json:"role"`
+
+	// Set on support tokens an admin minted to act as a user
+	ImpersonatedBy string `json:"impersonatedBy,omitempty"`
+	ReadOnly       bool   `json:"readOnly,omitempty"`
+
+	// Unix time of the login that started this session, carried over unchanged
+	// when the token is refreshed
+	SessionStart int64 `json:"sessionStart,omitempty"`
+
+	// Empty on tokens issued before refresh tokens existed, which are access tokens
+	TokenType string `json:"token_type,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// SessionExpired reports whether the login behind these claims is older than
+// maxLifetime. Tokens issued before sessionStart existed fall back to iat, and
+// tokens carrying neither are treated as expired. A zero maxLifetime disables the check.
+func (c *Claims) SessionExpired(maxLifetime time.Duration) bool {
+	return sessionExpired(c.SessionStart, c.IssuedAt, maxLifetime)
+}
+
+// RefreshClaims are the claims of a refresh token. They carry only what is needed
+// to issue the next access token, and are never accepted by JWTAuthMiddleware.
+type RefreshClaims struct {
+	ID           string `json:"id"`
+	Role         string `json:"role"`
+	SessionStart int64  `json:"sessionStart"`
+	TokenType    string `json:"token_type"`
 	jwt.RegisteredClaims
 }
 
+// SessionExpired reports whether the login behind the refresh token is older than
+// maxLifetime, see Claims.SessionExpired
+func (c *RefreshClaims) SessionExpired(maxLifetime time.Duration) bool {
+	return sessionExpired(c.SessionStart, c.IssuedAt, maxLifetime)
+}
+
+func sessionExpired(start int64, issuedAt *jwt.NumericDate, maxLifetime time.Duration) bool {
+	if maxLifetime <= 0 {
+		return false
+	}
+	if start == 0 && issuedAt != nil {
+		start = issuedAt.Unix()
+	}
+	if start == 0 {
+		return true
+	}
+	return time.Since(time.Unix(start, 0)) > maxLifetime
+}
+
+// Token types, stored in the token_type claim
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
 // Context keys
 const (
-	EntityID = "id"
-	RoleKey  = "role"
+	EntityID        = "id"
+	RoleKey         = "role"
+	TokenExpiryKey  = "tokenExpiry"
+	TokenIssuedKey  = "tokenIssued"
+	TokenIDKey      = "tokenId"
+	ImpersonatorKey = "impersonatedBy"
 )
 
 // Role constants
@@ -34,41 +90,54 @@ const (
 	RoleRestaurant = "restaurant"
 )
 
-// JWTAuthMiddleware handles JWT authentication and role verification
+// JWTAuthMiddleware handles JWT authentication and role verification. When cookie
+// auth is enabled, requests without an Authorization header may carry the token in
+// the auth cookie instead, and CSRFMiddleware must guard them.
 func JWTAuthMiddleware() gin.HandlerFunc {
+	cfg := config.LoadConfig()
+	// The same configuration already built the token service, which fails startup
+	// when it is invalid
+	keys, err := KeyRingFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("Invalid JWT key configuration: %v", err)
+	}
+
 	return func(c *gin.Context) {
-		// Get token from Authorization header
+		// Get token from Authorization header, falling back to the auth cookie
 		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "Authorization header is required",
-			})
-			c.Abort()
-			return
+		var tokenString string
+		fromCookie := false
+		if authHeader == "" && cfg.AuthCookieEnabled {
+			if cookie, err := c.Cookie(cfg.AuthCookieName); err == nil && cookie != "" {
+				tokenString, fromCookie = cookie, true
+			}
 		}
 
-		// Remove Bearer prefix
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "Invalid token format",
-			})
-			c.Abort()
-			return
+		if !fromCookie {
+			if authHeader == "" {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"success": false,
+					"message": "Authorization header is required",
+				})
+				c.Abort()
+				return
+			}
+
+			// Remove Bearer prefix
+			tokenString = strings.TrimPrefix(authHeader, "Bearer ")
+			if tokenString == authHeader {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"success": false,
+					"message": "Invalid token format",
+				})
+				c.Abort()
+				return
+			}
 		}
 
 		// Parse and validate token
 		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			// Validate signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
-			}
-			config := config.LoadConfig()
-			return []byte(config.JWTSecretKey), nil
-		})
+		token, err := jwt.ParseWithClaims(tokenString, claims, keys.Keyfunc)
 
 		if err != nil || !token.Valid {
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -89,77 +158,85 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Store user information in context
-		c.Set(EntityID, claims.ID)
-		c.Set(RoleKey, claims.Role)
-
-		// Log the values that were set
-		entityID, _ := c.Get(EntityID)
-		role, _ := c.Get(RoleKey)
-		log.Printf("Context values set - EntityID: %v, Role: %v", entityID, role)
-
-		c.Next()
-	}
-}
-
-// AdminAuthMiddleware verifies if the user has admin role
-func AdminAuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		role, exists := c.Get(RoleKey)
-		if !exists {
+		// Refresh tokens may only be exchanged for access tokens
+		if claims.TokenType == TokenTypeRefresh {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"success": false,
-				"message": "Role information not found",
+				"message": "Refresh tokens cannot be used to access the API",
 			})
 			c.Abort()
 			return
 		}
 
-		if role != RoleAdmin {
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"message": "Admin access required",
-			})
+		// Access tokens end with their session, so refreshing up to the limit cannot
+		// leave one usable past it. Clients get the same code as from a refused refresh.
+		if claims.SessionExpired(cfg.MaxSessionLifetime) {
+			c.JSON(http.StatusUnauthorized, model.ErrorResponseWithCode(model.CodeSessionExpired, model.ErrSessionExpired, nil))
 			c.Abort()
 			return
 		}
 
-		c.Next()
-	}
-}
-
-// RestaurantAuthMiddleware verifies if the user has restaurant role
-func RestaurantAuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		role, exists := c.Get(RoleKey)
-		if !exists {
+		if tokenRevoked(claims.RegisteredClaims.ID) {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"success": false,
-				"message": "Role information not found",
+				"message": "Token has been revoked",
 			})
 			c.Abort()
 			return
 		}
 
-		if role != RoleRestaurant {
+		// Store user information in context
+		c.Set(EntityID, claims.ID)
+		c.Set(RoleKey, claims.Role)
+		c.Set(TokenExpiryKey, claims.ExpiresAt.Time)
+		c.Set(TokenIDKey, claims.RegisteredClaims.ID)
+		if claims.IssuedAt != nil {
+			c.Set(TokenIssuedKey, claims.IssuedAt.Time)
+		}
+
+		// Read-only tokens may only be used to look, never to change anything
+		if claims.ReadOnly && c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
 			c.JSON(http.StatusForbidden, gin.H{
 				"success": false,
-				"message": "Restaurant access required",
+				"message": "Token is read-only",
 			})
 			c.Abort()
 			return
 		}
+		if claims.ImpersonatedBy != "" {
+			c.Set(ImpersonatorKey, claims.ImpersonatedBy)
+			log.Printf("Impersonated request - EntityID: %s, ImpersonatedBy: %s, %s %s", claims.ID, claims.ImpersonatedBy, c.Request.Method, c.Request.URL.Path)
+		}
+
+		// Log the values that were set
+		entityID, _ := c.Get(EntityID)
+		role, _ := c.Get(RoleKey)
+		log.Printf("Context values set - EntityID: %v, Role: %v", entityID, role)
 
 		c.Next()
 	}
 }
 
-// UserAuthMiddleware verifies if the user has user role
-func UserAuthMiddleware() gin.HandlerFunc {
+// RequireAnyRole admits requests whose token carries one of roles and rejects
+// the rest with 403. Must run after JWTAuthMiddleware.
+func RequireAnyRole(roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(roles))
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		allowed[role] = true
+		names[i] = role
+	}
+	if len(names) > 0 {
+		names[0] = strings.ToUpper(names[0][:1]) + names[0][1:]
+	}
+	message := strings.Join(names, " or ") + " access required"
+
 	return func(c *gin.Context) {
-		role, exists := c.Get(RoleKey)
+		role, exists := GetEntityRole(c)
 		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{
+			// JWTAuthMiddleware always sets the role, so reaching here means it was not applied
+			log.Printf("Role information not found in context for %s %s, JWTAuthMiddleware missing", c.Request.Method, c.FullPath())
+			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
 				"message": "Role information not found",
 			})
@@ -167,10 +244,10 @@ func UserAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		if role != RoleUser {
+		if !allowed[role] {
 			c.JSON(http.StatusForbidden, gin.H{
 				"success": false,
-				"message": "User access required",
+				"message": message,
 			})
 			c.Abort()
 			return
@@ -180,8 +257,25 @@ func UserAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// AdminAuthMiddleware verifies if the user has admin role
+func AdminAuthMiddleware() gin.HandlerFunc {
+	return RequireAnyRole(RoleAdmin)
+}
+
+// RestaurantAuthMiddleware verifies if the user has restaurant role
+func RestaurantAuthMiddleware() gin.HandlerFunc {
+	return RequireAnyRole(RoleRestaurant)
+}
+
+// UserAuthMiddleware verifies if the user has user role
+func UserAuthMiddleware() gin.HandlerFunc {
+	return RequireAnyRole(RoleUser)
+}
+
 // UserBanCheckMiddleware checks if a user is banned before allowing access
 func UserBanCheckMiddleware(userClient User.UserServiceClient) gin.HandlerFunc {
+	timeout := config.LoadConfig().UserTimeout
+
 	return func(c *gin.Context) {
 		// Get user ID from the context (set by JWTAuthMiddleware)
 		userId, exists := GetEntityID(c)
@@ -191,7 +285,7 @@ func UserBanCheckMiddleware(userClient User.UserServiceClient) gin.HandlerFunc {
 			return
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := WithRequestTimeout(c, timeout)
 		defer cancel()
 
 		// Check if user is banned
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyedMutex hands out one lock per key, so work on the same key is serialized
+// while different keys proceed in parallel. Locks are dropped once no request
+// holds or waits for them. It is safe for concurrent use.
+type KeyedMutex struct {
+	mutex sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+// NewKeyedMutex creates an empty keyed mutex
+func NewKeyedMutex() *KeyedMutex {
+	return &KeyedMutex{locks: make(map[string]*keyLock)}
+}
+
+// Lock blocks until the lock for key is held and returns the function releasing it
+func (k *KeyedMutex) Lock(key string) func() {
+	k.mutex.Lock()
+	lock, exists := k.locks[key]
+	if !exists {
+		lock = &keyLock{}
+		k.locks[key] = lock
+	}
+	lock.refs++
+	k.mutex.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+
+		k.mutex.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mutex.Unlock()
+	}
+}
+
+// SerializePerEntityMiddleware runs the requests of each authenticated entity one
+// at a time, so concurrent mutations from one restaurant cannot race each other on
+// backend state. Must run after JWTAuthMiddleware.
+func SerializePerEntityMiddleware(locks *KeyedMutex) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entityID, exists := GetEntityID(c)
+		if !exists {
+			c.Next()
+			return
+		}
+
+		unlock := locks.Lock(entityID)
+		defer unlock()
+		c.Next()
+	}
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+	config "github.com/liju-github/FoodBuddyAPIGateway/configs"
+)
+
+// KeyRing holds the secrets tokens are signed with, by key ID, so the secret can
+// be rotated without logging everyone out: add the new key, start signing with
+// it, and remove the old one once the tokens it signed have expired. Tokens name
+// their key in the kid header, and tokens naming a key that is no longer on the
+// ring are rejected.
+type KeyRing struct {
+	signingKeyID string
+	keys         map[string][]byte
+
+	// unversioned verifies tokens without a kid, signed before key IDs existed.
+	// Nil rejects them.
+	unversioned []byte
+}
+
+// NewKeyRing creates a key ring that signs with keys[signingKeyID]. Tokens without
+// a kid are verified against unversionedSecret, and are rejected when it is empty.
+// With no keys, tokens are signed with unversionedSecret alone and carry no kid,
+// as they did before key IDs existed.
+func NewKeyRing(signingKeyID string, keys map[string]string, unversionedSecret string) (*KeyRing, error) {
+	if len(keys) == 0 {
+		if signingKeyID != "" {
+			return nil, fmt.Errorf("signing key %q is not configured", signingKeyID)
+		}
+		return &KeyRing{unversioned: []byte(unversionedSecret)}, nil
+	}
+
+	if signingKeyID == "" {
+		return nil, errors.New("a signing key ID is required when JWT keys are configured")
+	}
+	if _, ok := keys[signingKeyID]; !ok {
+		return nil, fmt.Errorf("signing key %q is not configured", signingKeyID)
+	}
+	ring := &KeyRing{
+		signingKeyID: signingKeyID,
+		keys:         make(map[string][]byte, len(keys)),
+	}
+	for kid, secret := range keys {
+		ring.keys[kid] = []byte(secret)
+	}
+	if unversionedSecret != "" {
+		ring.unversioned = []byte(unversionedSecret)
+	}
+	return ring, nil
+}
+
+// KeyRingFromConfig creates the key ring described by cfg
+func KeyRingFromConfig(cfg config.Config) (*KeyRing, error) {
+	return NewKeyRing(cfg.JWTSigningKeyID, cfg.JWTKeys, cfg.JWTSecretKey)
+}
+
+// Sign signs claims with the current signing key, naming it in the kid header
+func (k *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if k.signingKeyID == "" {
+		return token.SignedString(k.unversioned)
+	}
+	token.Header["kid"] = k.signingKeyID
+	return token.SignedString(k.keys[k.signingKeyID])
+}
+
+// Keyfunc returns the secret the token was signed with, for jwt.Parse. It fails
+// for tokens signed with a retired or unknown key.
+func (k *KeyRing) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, errors.New("unexpected signing method")
+	}
+
+	kid, hasKid := token.Header["kid"]
+	if !hasKid {
+		if k.unversioned == nil {
+			return nil, errors.New("tokens without a key ID are no longer accepted")
+		}
+		return k.unversioned, nil
+	}
+	id, ok := kid.(string)
+	if !ok {
+		return nil, errors.New("malformed key ID")
+	}
+	secret, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("key %q is retired or unknown", id)
+	}
+	return secret, nil
+}
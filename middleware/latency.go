@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"expvar"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// slaBreaches counts the requests that exceeded their latency SLA, keyed by
+// "METHOD /route". It is published through expvar under "sla_breaches".
+var slaBreaches = expvar.NewMap("sla_breaches")
+
+// LatencyMiddleware logs how long each request took. Requests slower than their
+// route's SLA are logged at warn level with sla_breach set and counted in
+// sla_breaches, so alerts can target specific slow endpoints. routeSLAs is keyed
+// by route template, e.g. /api/orders/:orderId/eta; routes without an entry use
+// defaultSLA, and an SLA of zero is never breached.
+func LatencyMiddleware(logger *logrus.Logger, defaultSLA time.Duration, routeSLAs map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		latency := time.Since(start)
+
+		route := c.FullPath()
+		entry := RequestLogger(c, logger).WithFields(logrus.Fields{
+			"route":     route,
+			"status":    c.Writer.Status(),
+			"latencyMs": latency.Milliseconds(),
+		})
+
+		sla, ok := routeSLAs[route]
+		if !ok {
+			sla = defaultSLA
+		}
+		if sla > 0 && latency > sla {
+			slaBreaches.Add(c.Request.Method+" "+route, 1)
+			entry.WithFields(logrus.Fields{
+				"sla_breach": true,
+				"slaMs":      sla.Milliseconds(),
+			}).Warn("Request exceeded its latency SLA")
+			return
+		}
+		entry.Debug("Request completed")
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liju-github/FoodBuddyAPIGateway/model"
+)
+
+// MaintenanceMode holds the gateway's maintenance flag. It is safe for concurrent use.
+type MaintenanceMode struct {
+	enabled    atomic.Bool
+	retryAfter time.Duration
+}
+
+// NewMaintenanceMode creates a maintenance flag with its initial state and the
+// Retry-After duration advertised to clients while it is enabled
+func NewMaintenanceMode(enabled bool, retryAfter time.Duration) *MaintenanceMode {
+	mode := &MaintenanceMode{retryAfter: retryAfter}
+	mode.enabled.Store(enabled)
+	return mode
+}
+
+// Enabled reports whether maintenance mode is on
+func (m *MaintenanceMode) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// SetEnabled turns maintenance mode on or off
+func (m *MaintenanceMode) SetEnabled(enabled bool) {
+	m.enabled.Store(enabled)
+}
+
+// RetryAfter returns the duration clients are told to wait while maintenance mode is on
+func (m *MaintenanceMode) RetryAfter() time.Duration {
+	return m.retryAfter
+}
+
+// MaintenanceMiddleware returns 503 for every request while maintenance mode is on,
+// except for paths under the exempt prefixes (admin and health routes)
+func MaintenanceMiddleware(mode *MaintenanceMode, exemptPrefixes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !mode.Enabled() {
+			c.Next()
+			return
+		}
+
+		for _, prefix := range exemptPrefixes {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		c.Header("Retry-After", fmt.Sprintf("%d", int(mode.RetryAfter().Seconds())))
+		c.JSON(http.StatusServiceUnavailable, model.ErrorResponse(model.ErrUnderMaintenance, nil))
+		c.Abort()
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NoBodyMiddleware rejects requests that carry a body on any of the given methods.
+// Handlers behind these methods read their input from the path, query or token only,
+// so a body is either a client mistake or an attempt to smuggle mutation parameters.
+func NoBodyMiddleware(methods ...string) gin.HandlerFunc {
+	guarded := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		guarded[method] = true
+	}
+
+	return func(c *gin.Context) {
+		if !guarded[c.Request.Method] {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > 0 || len(c.Request.TransferEncoding) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": c.Request.Method + " requests must not include a body",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
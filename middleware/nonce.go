@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NonceHeader carries the one-time value clients send with replay protected requests
+const NonceHeader = "X-Nonce"
+
+// NonceTracker remembers the nonces used with each token until the token expires.
+// Tokens are identified by their jti. It is safe for concurrent use.
+type NonceTracker struct {
+	mutex sync.Mutex
+	used  map[string]time.Time
+}
+
+// NewNonceTracker creates an empty nonce tracker
+func NewNonceTracker() *NonceTracker {
+	return &NonceTracker{used: make(map[string]time.Time)}
+}
+
+// Use records the nonce for the token and reports whether it was unused. The
+// record is kept until expiresAt, after which the token itself is rejected.
+func (t *NonceTracker) Use(tokenID, nonce string, expiresAt time.Time) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	for key, expiry := range t.used {
+		if now.After(expiry) {
+			delete(t.used, key)
+		}
+	}
+
+	key := tokenID + "|" + nonce
+	if _, exists := t.used[key]; exists {
+		return false
+	}
+	t.used[key] = expiresAt
+	return true
+}
+
+// ReplayProtectionMiddleware requires a one-time X-Nonce header on the route and
+// rejects a nonce already used with the same token with 409. It must run after
+// JWTAuthMiddleware, which provides the token expiry.
+func ReplayProtectionMiddleware(tracker *NonceTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		nonce := c.GetHeader(NonceHeader)
+		if nonce == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": NonceHeader + " header is required",
+			})
+			c.Abort()
+			return
+		}
+
+		expiresAt, ok := c.Get(TokenExpiryKey)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Token information not found",
+			})
+			c.Abort()
+			return
+		}
+
+		if !tracker.Use(nonceScope(c), nonce, expiresAt.(time.Time)) {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"message": "Nonce has already been used",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// nonceScope identifies the token a nonce is used with by its jti, which is the same
+// whether the token came in the Authorization header or the auth cookie. Tokens
+// without a jti fall back to their entity, so their nonces are shared by all of the
+// entity's tokens.
+func nonceScope(c *gin.Context) string {
+	if tokenID := c.GetString(TokenIDKey); tokenID != "" {
+		return "jti:" + tokenID
+	}
+	role, _ := GetEntityRole(c)
+	entityID, _ := GetEntityID(c)
+	return "entity:" + role + ":" + entityID
+}
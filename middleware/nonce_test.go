@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReplayProtectionKeyedByToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Cookie authenticated requests carry no Authorization header
+	router := gin.New()
+	router.POST("/", func(c *gin.Context) {
+		c.Set(EntityID, "admin1")
+		c.Set(RoleKey, RoleAdmin)
+		c.Set(TokenIDKey, c.Query("jti"))
+		c.Set(TokenExpiryKey, time.Now().Add(time.Hour))
+		c.Next()
+	}, ReplayProtectionMiddleware(NewNonceTracker()), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	post := func(jti, nonce string) int {
+		req := httptest.NewRequest(http.MethodPost, "/?jti="+jti, nil)
+		req.Header.Set(NonceHeader, nonce)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	if code := post("token-a", "n1"); code != http.StatusOK {
+		t.Fatalf("first use: got %d, want 200", code)
+	}
+	if code := post("token-a", "n1"); code != http.StatusConflict {
+		t.Errorf("replay with the same token: got %d, want 409", code)
+	}
+	if code := post("token-b", "n1"); code != http.StatusOK {
+		t.Errorf("same nonce with another token: got %d, want 200", code)
+	}
+
+	// Tokens without a jti share their entity's nonces
+	if code := post("", "n2"); code != http.StatusOK {
+		t.Fatalf("first use without a jti: got %d, want 200", code)
+	}
+	if code := post("", "n2"); code != http.StatusConflict {
+		t.Errorf("replay without a jti: got %d, want 409", code)
+	}
+}
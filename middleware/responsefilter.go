@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ResponseFieldRules decide which internal fields are stripped from JSON responses.
+// A Deny entry such as "verificationCode" removes the field wherever it appears,
+// while "profile.reputation" removes it only from the objects held under a
+// "profile" key, which is how a backend type shows up in a response. Allow lists,
+// per route template, the denied entries that route may still return, e.g. ban
+// details on admin routes.
+type ResponseFieldRules struct {
+	Deny  []string
+	Allow map[string][]string
+}
+
+// deniedFields is a compiled set of Deny entries
+type deniedFields struct {
+	anywhere map[string]bool
+	scoped   map[string]map[string]bool
+}
+
+func compileDeniedFields(deny []string, allowed map[string]bool) deniedFields {
+	fields := deniedFields{
+		anywhere: make(map[string]bool),
+		scoped:   make(map[string]map[string]bool),
+	}
+	for _, entry := range deny {
+		if allowed[entry] {
+			continue
+		}
+		parent, field, scoped := strings.Cut(entry, ".")
+		if !scoped {
+			fields.anywhere[entry] = true
+			continue
+		}
+		if fields.scoped[parent] == nil {
+			fields.scoped[parent] = make(map[string]bool)
+		}
+		fields.scoped[parent][field] = true
+	}
+	return fields
+}
+
+func (d deniedFields) empty() bool {
+	return len(d.anywhere) == 0 && len(d.scoped) == 0
+}
+
+// ResponseFilterMiddleware strips the fields denied by rules from every JSON
+// response, so internal backend fields cannot leak through endpoints that forward
+// backend responses as they are. Responses that are not JSON, or that fail to
+// parse, are sent unchanged. Empty rules disable it.
+func ResponseFilterMiddleware(logger *logrus.Logger, rules ResponseFieldRules) gin.HandlerFunc {
+	defaults := compileDeniedFields(rules.Deny, nil)
+	if defaults.empty() {
+		return func(c *gin.Context) { c.Next() }
+	}
+	perRoute := make(map[string]deniedFields, len(rules.Allow))
+	for route, allow := range rules.Allow {
+		allowed := make(map[string]bool, len(allow))
+		for _, entry := range allow {
+			allowed[entry] = true
+		}
+		perRoute[route] = compileDeniedFields(rules.Deny, allowed)
+	}
+
+	return func(c *gin.Context) {
+		writer := &jsonBufferWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		defer func() { c.Writer = writer.ResponseWriter }()
+
+		c.Next()
+
+		if !writer.buffering {
+			return
+		}
+		denied, ok := perRoute[c.FullPath()]
+		if !ok {
+			denied = defaults
+		}
+		body, err := stripDeniedFields(writer.buffer.Bytes(), denied)
+		if err != nil {
+			RequestLogger(c, logger).WithError(err).Warn("Failed to filter response fields")
+		}
+		writer.Header().Del("Content-Length")
+		writer.ResponseWriter.Write(body)
+	}
+}
+
+// stripDeniedFields removes the denied fields at any depth of the JSON document
+// body. It returns body unchanged when nothing was removed, so field order and
+// formatting are preserved.
+func stripDeniedFields(body []byte, denied deniedFields) ([]byte, error) {
+	if denied.empty() {
+		return body, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return body, err
+	}
+
+	if !stripDeniedValue(document, "", denied) {
+		return body, nil
+	}
+	filtered, err := json.Marshal(document)
+	if err != nil {
+		return body, err
+	}
+	return filtered, nil
+}
+
+// stripDeniedValue removes denied fields from value, which is held under the key
+// parent, and reports whether anything was removed
+func stripDeniedValue(value interface{}, parent string, denied deniedFields) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if denied.anywhere[key] || denied.scoped[parent][key] {
+				delete(v, key)
+				changed = true
+				continue
+			}
+			if stripDeniedValue(child, key, denied) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		// Elements of an array belong to the key holding the array
+		for _, child := range v {
+			if stripDeniedValue(child, parent, denied) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShutdownState is raised once the gateway starts shutting down. It is safe for
+// concurrent use.
+type ShutdownState struct {
+	draining atomic.Bool
+	inFlight atomic.Int64
+}
+
+// NewShutdownState creates a shutdown flag that is not yet raised
+func NewShutdownState() *ShutdownState {
+	return &ShutdownState{}
+}
+
+// Begin raises the flag, after which new requests are turned away
+func (s *ShutdownState) Begin() {
+	s.draining.Store(true)
+}
+
+// Draining reports whether shutdown has begun
+func (s *ShutdownState) Draining() bool {
+	return s.draining.Load()
+}
+
+// InFlight returns the number of requests still being served
+func (s *ShutdownState) InFlight() int64 {
+	return s.inFlight.Load()
+}
+
+// ShutdownMiddleware rejects requests with 503 once shutdown has begun, and asks
+// the client to close the connection so it reconnects to another instance.
+// Requests already in progress are counted and left to finish. Register it first
+// so rejected requests do no other work.
+func ShutdownMiddleware(state *ShutdownState) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if state.Draining() {
+			c.Header("Connection", "close")
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"success": false,
+				"message": "Server is shutting down",
+			})
+			c.Abort()
+			return
+		}
+
+		state.inFlight.Add(1)
+		defer state.inFlight.Add(-1)
+		c.Next()
+	}
+}
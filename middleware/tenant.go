@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// TenantHeader carries the brand a request is made for
+	TenantHeader = "X-Tenant-ID"
+	// TenantKey is the context key holding the resolved tenant
+	TenantKey = "tenant"
+	// TenantMetadataKey is the gRPC metadata key the tenant is forwarded under
+	TenantMetadataKey = "x-tenant-id"
+)
+
+// TenantMiddleware resolves the tenant of each request from the X-Tenant-ID header
+// or, failing that, the first label of the Host header, and rejects requests whose
+// tenant is not one of tenants. The tenant is stored in the context and attached
+// to the request context as outgoing gRPC metadata, so backend calls rooted at the
+// request context carry it.
+func TenantMiddleware(tenants []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(tenants))
+	for _, tenant := range tenants {
+		allowed[strings.ToLower(tenant)] = true
+	}
+
+	return func(c *gin.Context) {
+		tenant := resolveTenant(c.Request)
+		if !allowed[tenant] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Unknown tenant",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set(TenantKey, tenant)
+		c.Request = c.Request.WithContext(metadata.AppendToOutgoingContext(c.Request.Context(), TenantMetadataKey, tenant))
+		c.Next()
+	}
+}
+
+// GetTenant returns the tenant resolved for the request, if any
+func GetTenant(c *gin.Context) (string, bool) {
+	tenant, exists := c.Get(TenantKey)
+	if !exists {
+		return "", false
+	}
+	value, ok := tenant.(string)
+	return value, ok
+}
+
+// resolveTenant prefers the explicit header, then the subdomain of the Host header
+func resolveTenant(r *http.Request) string {
+	if tenant := strings.TrimSpace(r.Header.Get(TenantHeader)); tenant != "" {
+		return strings.ToLower(tenant)
+	}
+
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	subdomain, _, ok := strings.Cut(host, ".")
+	if !ok {
+		return ""
+	}
+	return strings.ToLower(subdomain)
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// deadlineWriter drops whatever the handler writes once the request deadline has
+// passed, unless the response was already under way, so the timeout reply is not
+// mixed with the handler's own error
+type deadlineWriter struct {
+	gin.ResponseWriter
+	ctx context.Context
+}
+
+func (w *deadlineWriter) expired() bool {
+	return errors.Is(w.ctx.Err(), context.DeadlineExceeded) && !w.ResponseWriter.Written()
+}
+
+func (w *deadlineWriter) WriteHeader(code int) {
+	if !w.expired() {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *deadlineWriter) Write(data []byte) (int, error) {
+	if w.expired() {
+		return 0, http.ErrHandlerTimeout
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *deadlineWriter) WriteString(s string) (int, error) {
+	if w.expired() {
+		return 0, http.ErrHandlerTimeout
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// RequestTimeoutMiddleware bounds the whole request, however many backend calls the
+// handler makes, by giving it a context that expires after timeout. Backend calls
+// rooted at the request fail once it expires and the client gets 504 instead of
+// the handler's response. Responses already being written when the deadline passes
+// are left to finish. A zero timeout disables it.
+func RequestTimeoutMiddleware(logger *logrus.Logger, timeout time.Duration) gin.HandlerFunc {
+	if timeout <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		writer := &deadlineWriter{ResponseWriter: c.Writer, ctx: ctx}
+		c.Writer = writer
+		defer func() { c.Writer = writer.ResponseWriter }()
+
+		c.Next()
+
+		if !writer.expired() {
+			return
+		}
+		RequestLogger(c, logger).WithField("timeout", timeout.String()).Warn("Request exceeded its deadline")
+		c.Writer = writer.ResponseWriter
+		c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{
+			"success": false,
+			"message": "Request timed out",
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// TimestampMiddleware rewrites the named fields of JSON responses to RFC3339 UTC
+// strings, whatever format the backend used. Responses that are not JSON, or that
+// fail to parse, are sent unchanged. An empty field list disables it.
+func TimestampMiddleware(logger *logrus.Logger, fields []string) gin.HandlerFunc {
+	if len(fields) == 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	names := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		names[field] = true
+	}
+
+	return func(c *gin.Context) {
+		writer := &jsonBufferWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		defer func() { c.Writer = writer.ResponseWriter }()
+
+		c.Next()
+
+		if !writer.buffering {
+			return
+		}
+		body, _, err := normalizeTimestamps(writer.buffer.Bytes(), names)
+		if err != nil {
+			RequestLogger(c, logger).WithError(err).Warn("Failed to normalize response timestamps")
+		}
+		writer.Header().Del("Content-Length")
+		writer.ResponseWriter.Write(body)
+	}
+}
+
+// timestampLayouts are the string formats recognized as timestamps. Layouts without
+// a zone are read as UTC.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	time.RFC1123,
+	time.RFC1123Z,
+}
+
+// unixMillisThreshold separates unix seconds from unix milliseconds: seconds stay
+// below it until the year 33658, milliseconds have exceeded it since 2001
+const unixMillisThreshold = 1e12
+
+// normalizeTimestamps rewrites the values of the named fields, at any depth of the
+// JSON document body, to RFC3339 UTC strings. Unix seconds or milliseconds, as
+// numbers or numeric strings, and the layouts in timestampLayouts are recognized;
+// other values are left alone. It reports whether anything changed, and returns
+// body unchanged when nothing did so field order and formatting are preserved.
+func normalizeTimestamps(body []byte, fields map[string]bool) ([]byte, bool, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return body, false, err
+	}
+
+	if !normalizeTimestampValue(document, fields) {
+		return body, false, nil
+	}
+	normalized, err := json.Marshal(document)
+	if err != nil {
+		return body, false, err
+	}
+	return normalized, true, nil
+}
+
+func normalizeTimestampValue(value interface{}, fields map[string]bool) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if fields[key] {
+				if normalized, ok := formatTimestamp(child); ok && normalized != child {
+					v[key] = normalized
+					changed = true
+					continue
+				}
+			}
+			if normalizeTimestampValue(child, fields) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if normalizeTimestampValue(child, fields) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// formatTimestamp returns value as an RFC3339 UTC string when it is a recognized timestamp
+func formatTimestamp(value interface{}) (string, bool) {
+	var raw string
+	switch v := value.(type) {
+	case json.Number:
+		raw = v.String()
+	case string:
+		raw = v
+	default:
+		return "", false
+	}
+	if raw == "" {
+		return "", false
+	}
+
+	if number, err := strconv.ParseFloat(raw, 64); err == nil {
+		if number <= 0 {
+			return "", false
+		}
+		if number >= unixMillisThreshold {
+			return time.UnixMilli(int64(number)).UTC().Format(time.RFC3339), true
+		}
+		return time.Unix(int64(number), 0).UTC().Format(time.RFC3339), true
+	}
+
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t.UTC().Format(time.RFC3339), true
+		}
+	}
+	return "", false
+}
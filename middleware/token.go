@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TokenService signs the tokens the gateway issues, with the key ring
+// JWTAuthMiddleware verifies them against
+type TokenService struct {
+	keys      *KeyRing
+	accessTTL time.Duration
+}
+
+// NewTokenService creates a token service whose access tokens live for accessTTL
+func NewTokenService(keys *KeyRing, accessTTL time.Duration) *TokenService {
+	return &TokenService{
+		keys:      keys,
+		accessTTL: accessTTL,
+	}
+}
+
+// AccessTTL is the lifetime of the access tokens the service issues
+func (t *TokenService) AccessTTL() time.Duration {
+	return t.accessTTL
+}
+
+// Generate signs an access token for a fresh login, which starts a new session
+func (t *TokenService) Generate(id, role string) (string, error) {
+	return t.GenerateForSession(id, role, time.Now().Unix())
+}
+
+// GenerateForSession signs an access token belonging to the session that started
+// at sessionStart, a Unix time
+func (t *TokenService) GenerateForSession(id, role string, sessionStart int64) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	return t.sign(jwt.MapClaims{
+		"id":           id,
+		"role":         role,
+		"jti":          jti,
+		"exp":          now.Add(t.accessTTL).Unix(),
+		"created":      now.Unix(),
+		"iat":          now.Unix(),
+		"sessionStart": sessionStart,
+		"token_type":   TokenTypeAccess,
+	})
+}
+
+// GenerateRefresh signs a refresh token valid for ttl, belonging to the session
+// that started at sessionStart
+func (t *TokenService) GenerateRefresh(id, role string, sessionStart int64, ttl time.Duration) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	return t.sign(RefreshClaims{
+		ID:           id,
+		Role:         role,
+		SessionStart: sessionStart,
+		TokenType:    TokenTypeRefresh,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	})
+}
+
+// GenerateImpersonation signs a read-only user token valid for ttl, flagged with
+// the admin who requested it
+func (t *TokenService) GenerateImpersonation(userID, adminID string, ttl time.Duration) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+	// Each impersonation is a session of its own
+	now := time.Now()
+	return t.sign(jwt.MapClaims{
+		"id":             userID,
+		"role":           RoleUser,
+		"jti":            jti,
+		"impersonatedBy": adminID,
+		"readOnly":       true,
+		"exp":            now.Add(ttl).Unix(),
+		"created":        now.Unix(),
+		"iat":            now.Unix(),
+		"sessionStart":   now.Unix(),
+		"token_type":     TokenTypeAccess,
+	})
+}
+
+// ParseRefresh verifies a refresh token and returns its claims. It fails for
+// expired or revoked tokens and for tokens that are not refresh tokens.
+func (t *TokenService) ParseRefresh(tokenString string) (*RefreshClaims, error) {
+	claims := &RefreshClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, t.keys.Keyfunc, jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid || claims.TokenType != TokenTypeRefresh {
+		return nil, errors.New("not a refresh token")
+	}
+	if tokenRevoked(claims.RegisteredClaims.ID) {
+		return nil, errors.New("refresh token has been revoked")
+	}
+	return claims, nil
+}
+
+func (t *TokenService) sign(claims jwt.Claims) (string, error) {
+	return t.keys.Sign(claims)
+}
+
+// newTokenID returns a random jti, the key tokens are revoked by
+func newTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -4,11 +4,14 @@ package model
 const (
 	// Request validation errors
 	ErrInvalidRequestFormat       = "Invalid request format"
+	ErrMalformedJSON              = "Malformed JSON body"
+	ErrEmptyRequestBody           = "Request body is required"
 	ErrInvalidEmailFormat         = "Invalid email format, must be a valid email address"
-	ErrPasswordTooShort           = "Password must be at least 8 characters and contain only letters, numbers, and special characters"
+	ErrEmailDomainNotAllowed      = "Email domain not allowed"
 	ErrInvalidNameFormat          = "Name must be 2-50 characters long and contain only letters and spaces"
 	ErrInvalidPhoneFormat         = "Phone number must be 10 digits"
 	ErrInvalidPincodeFormat       = "Pincode must be 6 digits"
+	ErrUnserviceablePincode       = "We don't serve this area yet"
 	ErrEmptyStreetName            = "Street name cannot be empty"
 	ErrEmptyLocality              = "Locality cannot be empty"
 	ErrEmptyState                 = "State cannot be empty"
@@ -16,6 +19,9 @@ const (
 	ErrAddressIDRequired          = "Address ID is required"
 	ErrAuthorizationTokenRequired = "Authorization token required"
 	ErrFailedGenerateToken        = "Failed to generate token"
+	ErrSessionExpired             = "Session has expired, please log in again"
+	ErrInvalidRefreshToken        = "Invalid or expired refresh token"
+	ErrInvalidVerificationCode    = "Invalid verification code format"
 
 	// Authentication errors
 	ErrUserIDNotFound     = "User ID not found in context"
@@ -37,12 +43,57 @@ const (
 	ErrFailedUnbanUser         = "Failed to unban user"
 	ErrFailedCheckBan          = "Failed to check ban status"
 	ErrFailedRetrieveUsers     = "Failed to retrieve users"
+	ErrFailedImpersonate       = "Failed to impersonate user"
+
+	// Availability errors
+	ErrUnderMaintenance        = "Service is under maintenance"
+	ErrAdminServiceUnavailable = "Admin service is unavailable, please try again later"
+
+	// Inventory errors
+	ErrInvalidAvailabilityFilter = "Availability must be one of in_stock, low_stock or out_of_stock"
+	ErrInvalidSortOrder          = "Sort must be stock_asc or stock_desc"
+	ErrFailedRetrieveInventory   = "Failed to retrieve inventory"
+	ErrDuplicateProductName      = "A product with this name already exists"
+
+	// Favorites errors
+	ErrRestaurantIDRequired    = "Restaurant ID is required"
+	ErrRestaurantNotFound      = "Restaurant not found"
+	ErrRestaurantUnavailable   = "Restaurant is currently unavailable"
+	ErrFailedAddFavorite       = "Failed to add favorite"
+	ErrFailedRemoveFavorite    = "Failed to remove favorite"
+	ErrFailedRetrieveFavorites = "Failed to retrieve favorites"
+	ErrFavoritesNotSupported   = "Favorites are not supported yet"
 )
 
 // Response messages
 const (
 	MsgAddressUpdated = "Address updated successfully"
+	MsgAddressChecked = "Address validated"
 	MsgAddressDeleted = "Address deleted successfully"
 	MsgUserBanned     = "User banned successfully"
 	MsgUserUnbanned   = "User unbanned successfully"
+	MsgImpersonating  = "Read-only impersonation token issued"
+	MsgBulkModeration = "Bulk moderation completed"
+	MsgTokenRefreshed = "Token refreshed"
+	MsgLoggedOut      = "Logged out successfully"
+
+	MsgSignupSuccessful          = "Signup successful"
+	MsgSignupPendingVerification = "Signup successful, please verify your email before logging in"
+
+	MsgFavoriteAdded      = "Restaurant added to favorites"
+	MsgFavoriteRemoved    = "Restaurant removed from favorites"
+	MsgFavoritesRetrieved = "Favorites retrieved successfully"
+
+	MsgInventoryRetrieved = "Inventory retrieved successfully"
+
+	MsgMaintenanceUpdated = "Maintenance mode updated"
+	MsgMaintenanceStatus  = "Maintenance mode status retrieved"
+	MsgConfigRetrieved    = "Configuration retrieved"
+)
+
+// Machine readable error codes, for clients that need to tell failures apart
+const (
+	CodeAdminServiceUnavailable = "ADMIN_SERVICE_UNAVAILABLE"
+	CodeDuplicateProductName    = "DUPLICATE_PRODUCT_NAME"
+	CodeSessionExpired          = "SESSION_EXPIRED"
 )
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+)
+
+// phoneNumberRegex matches 10 digit numbers without a leading zero, which are
+// exactly the numbers that survive conversion to the backends' integer type
+var phoneNumberRegex = regexp.MustCompile(`^[1-9]\d{9}$`)
+
+// PhoneNumber is a 10 digit phone number in a request. It is kept as a string so
+// no digits are lost, and accepts both JSON strings and numbers so older clients
+// sending numbers keep working.
+type PhoneNumber string
+
+// UnmarshalJSON accepts a JSON string or number
+func (p *PhoneNumber) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*p = PhoneNumber(text)
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return errors.New("phone number must be a string or number")
+	}
+	*p = PhoneNumber(number.String())
+	return nil
+}
+
+// Valid reports whether the phone number is 10 digits without a leading zero
+func (p PhoneNumber) Valid() bool {
+	return phoneNumberRegex.MatchString(string(p))
+}
+
+// Uint64 converts the phone number to the integer type the gRPC contracts use.
+// It fails for numbers that would not convert back to the same digits.
+func (p PhoneNumber) Uint64() (uint64, error) {
+	if !p.Valid() {
+		return 0, errors.New(ErrInvalidPhoneFormat)
+	}
+	return strconv.ParseUint(string(p), 10, 64)
+}
+
+// PhoneNumberFromUint64 converts a phone number from the gRPC integer type
+func PhoneNumberFromUint64(number uint64) PhoneNumber {
+	return PhoneNumber(strconv.FormatUint(number, 10))
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // Address represents the address structure
 type Address struct {
 	StreetName string `json:"streetName" binding:"required"`
@@ -16,18 +18,18 @@ type LoginRequest struct {
 
 // SignupRequest represents the request structure for user signup
 type SignupRequest struct {
-	Email       string  `json:"email" binding:"required,email"`
-	Password    string  `json:"password" binding:"required,min=8"`
-	FirstName   string  `json:"firstName" binding:"required"`
-	LastName    string  `json:"lastName" binding:"required"`
-	PhoneNumber uint64  `json:"phoneNumber" binding:"required"`
-	Address     Address `json:"address" binding:"required"`
+	Email       string      `json:"email" binding:"required,email"`
+	Password    string      `json:"password" binding:"required,min=8"`
+	FirstName   string      `json:"firstName" binding:"required"`
+	LastName    string      `json:"lastName" binding:"required"`
+	PhoneNumber PhoneNumber `json:"phoneNumber" binding:"required"`
+	Address     Address     `json:"address" binding:"required"`
 }
 
 // UpdateProfileRequest represents the request structure for profile updates
 type UpdateProfileRequest struct {
-	Name        string `json:"name" `
-	PhoneNumber uint64 `json:"phoneNumber" `
+	Name        string      `json:"name" `
+	PhoneNumber PhoneNumber `json:"phoneNumber" `
 }
 
 // VerifyEmailRequest represents the request structure for email verification
@@ -40,6 +42,17 @@ type AddAddressRequest struct {
 	Address Address `json:"address" binding:"required"`
 }
 
+// ValidateAddressRequest represents the request structure for checking an address
+// without saving it. Its fields are optional so every problem can be reported at once.
+type ValidateAddressRequest struct {
+	Address struct {
+		StreetName string `json:"streetName"`
+		Locality   string `json:"locality"`
+		State      string `json:"state"`
+		Pincode    string `json:"pincode"`
+	} `json:"address"`
+}
+
 // GetAddressesRequest represents the request structure for getting addresses
 type GetAddressesRequest struct {
 }
@@ -65,14 +78,26 @@ type UnbanUserRequest struct {
 type CheckBanRequest struct {
 }
 
-// GetUserByTokenRequest represents the request structure for getting user by token
-type GetUserByTokenRequest struct {
-	Token string `json:"token" binding:"required"`
-}
-
 // GetAllUsersRequest represents an empty request for getting all users
 type GetAllUsersRequest struct{}
 
+// RefreshTokenRequest represents the request structure for exchanging a refresh token
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// LogoutRequest represents the request structure for logging out. The refresh
+// token is optional and revoked along with the access token when sent.
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// AdminLoginRequest represents the request structure for admin login
+type AdminLoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
 // RestaurantLoginRequest represents the request structure for restaurant login
 type RestaurantLoginRequest struct {
 	OwnerEmail string `json:"ownerEmail" binding:"required,email"`
@@ -81,9 +106,59 @@ type RestaurantLoginRequest struct {
 
 // RestaurantSignupRequest represents the request structure for restaurant signup
 type RestaurantSignupRequest struct {
-	RestaurantName string  `json:"restaurantName" binding:"required"`
-	OwnerEmail     string  `json:"ownerEmail" binding:"required,email"`
-	Password       string  `json:"password" binding:"required,min=8"`
-	PhoneNumber    uint64  `json:"phoneNumber" binding:"required"`
-	Address        Address `json:"address" binding:"required"`
+	RestaurantName string      `json:"restaurantName" binding:"required"`
+	OwnerEmail     string      `json:"ownerEmail" binding:"required,email"`
+	Password       string      `json:"password" binding:"required,min=8"`
+	PhoneNumber    PhoneNumber `json:"phoneNumber" binding:"required"`
+	Address        Address     `json:"address" binding:"required"`
+}
+
+// AddFavoriteRequest represents the request structure for favoriting a restaurant
+type AddFavoriteRequest struct {
+	RestaurantID string `json:"restaurantId" binding:"required"`
+}
+
+// MaintenanceModeRequest represents the request structure for toggling maintenance mode
+type MaintenanceModeRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// PlaceOrderRequest represents the request structure for placing an order from a restaurant cart.
+// ScheduledFor requests delivery at a later time. The order service cannot take a
+// delivery time yet, so orders that set it are rejected with 501, or with 400 when
+// the time is past or outside the restaurant's operating hours.
+type PlaceOrderRequest struct {
+	RestaurantID      string     `json:"restaurantId"`
+	DeliveryAddressID string     `json:"deliveryAddressId"`
+	ScheduledFor      *time.Time `json:"scheduledFor,omitempty"`
+}
+
+// UpdateOrderAddressRequest represents the request structure for changing an order's delivery address
+type UpdateOrderAddressRequest struct {
+	AddressID string `json:"addressId" binding:"required"`
+}
+
+// MergeCartRequest represents the request structure for merging a guest cart into the user's cart
+type MergeCartRequest struct {
+	GuestCartID string `json:"guestCartId" binding:"required"`
+}
+
+// BulkModerationRequest represents the request structure for banning or unbanning several users at once
+type BulkModerationRequest struct {
+	UserIDs []string `json:"userIds" binding:"required,min=1,max=100,dive,required"`
+	Reason  string   `json:"reason" binding:"required"`
+}
+
+// RestaurantStatusRequest represents the request structure for looking up several restaurants' status at once
+type RestaurantStatusRequest struct {
+	RestaurantIDs []string `json:"restaurantIds" binding:"required,min=1,max=100,dive,required"`
+}
+
+// MaxMenuImportRows caps the products of one menu import
+const MaxMenuImportRows = 500
+
+// MenuImportRequest is the JSON menu import body, the same document the JSON menu
+// export produces. Fields other than products are ignored.
+type MenuImportRequest struct {
+	Products []MenuProduct `json:"products" binding:"required,min=1,max=500"`
 }
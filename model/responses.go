@@ -1,11 +1,24 @@
 package model
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+	OrderCart "github.com/liju-github/CentralisedFoodbuddyMicroserviceProto/OrderCart"
+)
+
 // GenericResponse represents a generic API response
 type GenericResponse struct {
 	Success bool        `json:"success"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
+	Code    string      `json:"code,omitempty"`
 }
 
 // UserProfile represents user profile data
@@ -32,6 +45,315 @@ type AddressResponse struct {
 	Address   Address `json:"address"`
 }
 
+// FavoriteRestaurant represents a favorited restaurant with its summary data
+type FavoriteRestaurant struct {
+	RestaurantID   string  `json:"restaurantId"`
+	RestaurantName string  `json:"restaurantName"`
+	PhoneNumber    uint64  `json:"phoneNumber"`
+	Address        Address `json:"address"`
+	IsAvailable    bool    `json:"isAvailable"`
+}
+
+// InventoryProduct represents a product in a restaurant owner's inventory view
+type InventoryProduct struct {
+	ProductID   string  `json:"productId"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Category    string  `json:"category"`
+	Price       float64 `json:"price"`
+	Stock       int32   `json:"stock"`
+	IsLowStock  bool    `json:"isLowStock"`
+}
+
+// CartItemValidation reports whether a single cart item can still be ordered
+type CartItemValidation struct {
+	ProductID      string `json:"productId"`
+	RestaurantID   string `json:"restaurantId"`
+	ProductName    string `json:"productName"`
+	Quantity       int32  `json:"quantity"`
+	AvailableStock int32  `json:"availableStock"`
+	IsValid        bool   `json:"isValid"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+// CartValidationReport reports the availability of every item in the user's carts
+type CartValidationReport struct {
+	IsValid bool                 `json:"isValid"`
+	Items   []CartItemValidation `json:"items"`
+}
+
+// CartRestaurantSummary represents the totals of a single restaurant's cart
+type CartRestaurantSummary struct {
+	RestaurantID   string  `json:"restaurantId"`
+	RestaurantName string  `json:"restaurantName"`
+	ItemCount      int32   `json:"itemCount"`
+	Subtotal       float64 `json:"subtotal"`
+	IsAvailable    bool    `json:"isAvailable"`
+}
+
+// FetchFailure names one backend lookup of an aggregate response that failed
+type FetchFailure struct {
+	Source string `json:"source"`
+	Error  string `json:"error"`
+}
+
+// PartialResult is embedded in responses aggregated from several backend lookups.
+// When some of them fail the rest is still returned, with Partial set and the
+// failed lookups listed in Failures; both are omitted from complete responses.
+type PartialResult struct {
+	Partial  bool           `json:"partial,omitempty"`
+	Failures []FetchFailure `json:"failures,omitempty"`
+}
+
+// CartSummary aggregates the user's carts across all restaurants. The grand total
+// only counts carts from restaurants that are currently available.
+type CartSummary struct {
+	Restaurants []CartRestaurantSummary `json:"restaurants"`
+	TotalItems  int32                   `json:"totalItems"`
+	GrandTotal  float64                 `json:"grandTotal"`
+	PartialResult
+}
+
+// CartCount is the number of items across the user's carts, for the cart badge.
+// Restaurants breaks the total down by restaurant ID when requested.
+type CartCount struct {
+	TotalItems  int32            `json:"totalItems"`
+	Restaurants map[string]int32 `json:"restaurants,omitempty"`
+}
+
+// RestaurantImpact summarizes what a moderation action on a restaurant would affect.
+// Counts that could not be retrieved are listed in Failures and left at zero.
+type RestaurantImpact struct {
+	RestaurantID  string `json:"restaurantId"`
+	PendingOrders int    `json:"pendingOrders"`
+	ActiveCarts   int    `json:"activeCarts"`
+	Products      int    `json:"products"`
+	PartialResult
+}
+
+// TrendingProduct represents a product ranked by how much it was ordered recently
+type TrendingProduct struct {
+	ProductID      string  `json:"productId"`
+	ProductName    string  `json:"productName"`
+	Category       string  `json:"category"`
+	Price          float64 `json:"price"`
+	RestaurantID   string  `json:"restaurantId"`
+	RestaurantName string  `json:"restaurantName"`
+	OrderCount     int     `json:"orderCount"`
+	QuantitySold   int32   `json:"quantitySold"`
+}
+
+// BulkModerationResult reports the outcome of a bulk ban or unban for one user
+type BulkModerationResult struct {
+	UserID  string `json:"userId"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// CatalogVersion identifies the current state of the product catalog. Version
+// changes whenever the catalog does.
+type CatalogVersion struct {
+	Version      string `json:"version"`
+	LastModified string `json:"lastModified"`
+}
+
+// RestaurantStatus reports whether a restaurant is open by its operating hours and
+// whether it is banned. Error is set instead when the status could not be looked up.
+type RestaurantStatus struct {
+	IsOpen   bool   `json:"isOpen"`
+	IsBanned bool   `json:"isBanned"`
+	Error    string `json:"error,omitempty"`
+}
+
+// CartMergeItem reports how one guest cart item was merged into the user's cart.
+// PreviousQuantity is non-zero when the item was already in the user's cart.
+type CartMergeItem struct {
+	ProductID        string `json:"productId"`
+	RestaurantID     string `json:"restaurantId"`
+	GuestQuantity    int32  `json:"guestQuantity"`
+	PreviousQuantity int32  `json:"previousQuantity"`
+	AddedQuantity    int32  `json:"addedQuantity"`
+	Reason           string `json:"reason,omitempty"`
+}
+
+// CartMergeResult lists the guest cart items that were merged, possibly with a
+// reduced quantity, and those that could not be merged at all
+type CartMergeResult struct {
+	Merged  []CartMergeItem `json:"merged"`
+	Skipped []CartMergeItem `json:"skipped"`
+}
+
+// FulfillmentMetrics summarizes a restaurant's orders over a recent window. The order
+// service does not record when an order changes status, so acceptance and prep times
+// cannot be derived and are not reported.
+type FulfillmentMetrics struct {
+	RestaurantID      string         `json:"restaurantId"`
+	Window            string         `json:"window"`
+	TotalOrders       int            `json:"totalOrders"`
+	OrdersByStatus    map[string]int `json:"ordersByStatus"`
+	CancellationRate  float64        `json:"cancellationRate"`
+	DeliveredRevenue  float64        `json:"deliveredRevenue"`
+	AverageOrderValue float64        `json:"averageOrderValue"`
+}
+
+// OrderStatusCounts counts a restaurant's orders per status. Every known status is
+// present, with zero when the restaurant has no orders in it.
+type OrderStatusCounts struct {
+	RestaurantID string         `json:"restaurantId"`
+	TotalOrders  int            `json:"totalOrders"`
+	Counts       map[string]int `json:"counts"`
+}
+
+// DeliveryEstimate represents the estimated delivery time of an order
+type DeliveryEstimate struct {
+	OrderID       string  `json:"orderId"`
+	OrderStatus   string  `json:"orderStatus"`
+	EstimatedAt   string  `json:"estimatedAt"`
+	MinMinutes    int     `json:"minMinutes"`
+	MaxMinutes    int     `json:"maxMinutes"`
+	PrepMinutes   int     `json:"prepMinutes"`
+	TravelMinutes int     `json:"travelMinutes"`
+	DistanceKm    float64 `json:"distanceKm"`
+}
+
+// DeliveryFee represents the delivery fee breakdown for a restaurant and address pair
+type DeliveryFee struct {
+	RestaurantID string  `json:"restaurantId"`
+	AddressID    string  `json:"addressId"`
+	DistanceKm   float64 `json:"distanceKm"`
+	BaseFee      float64 `json:"baseFee"`
+	DistanceFee  float64 `json:"distanceFee"`
+	TotalFee     float64 `json:"totalFee"`
+}
+
+// PendingOrder is an order awaiting restaurant action, annotated with how long it
+// has been waiting. WaitingMinutes is omitted when the order's creation time is unknown.
+type PendingOrder struct {
+	*OrderCart.Order
+	WaitingMinutes *int `json:"waitingMinutes,omitempty"`
+}
+
+// PendingOrderQueue is a restaurant's queue of orders awaiting action, oldest first
+type PendingOrderQueue struct {
+	RestaurantID string          `json:"restaurantId"`
+	Count        int             `json:"count"`
+	Orders       []*PendingOrder `json:"orders"`
+}
+
+// DailyEarnings is one day of a restaurant's earnings
+type DailyEarnings struct {
+	Date        string  `json:"date"`
+	OrderCount  int     `json:"orderCount"`
+	Gross       float64 `json:"gross"`
+	Commission  float64 `json:"commission"`
+	NetEarnings float64 `json:"netEarnings"`
+}
+
+// EarningsSummary totals a restaurant's delivered orders over a date range, net of
+// the platform commission, with one entry per day of the range
+type EarningsSummary struct {
+	RestaurantID      string          `json:"restaurantId"`
+	From              string          `json:"from"`
+	To                string          `json:"to"`
+	CommissionPercent float64         `json:"commissionPercent"`
+	OrderCount        int             `json:"orderCount"`
+	Gross             float64         `json:"gross"`
+	Commission        float64         `json:"commission"`
+	NetEarnings       float64         `json:"netEarnings"`
+	Days              []DailyEarnings `json:"days"`
+}
+
+// TokenInfo describes the validity of the caller's token. IssuedAt is omitted for
+// tokens issued without an iat claim.
+type TokenInfo struct {
+	ID               string     `json:"id"`
+	Role             string     `json:"role"`
+	IssuedAt         *time.Time `json:"issuedAt,omitempty"`
+	ExpiresAt        time.Time  `json:"expiresAt"`
+	SecondsRemaining int64      `json:"secondsRemaining"`
+}
+
+// SessionTokens are the tokens issued on refresh. Token authenticates API calls for
+// ExpiresIn seconds, RefreshToken is exchanged for the next pair.
+type SessionTokens struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int64  `json:"expiresIn"`
+}
+
+// MenuProduct is one product of a menu export. It carries no IDs so a menu can be
+// imported into another restaurant.
+type MenuProduct struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Category    string  `json:"category"`
+	Price       float64 `json:"price"`
+	Stock       int32   `json:"stock"`
+}
+
+// MenuExport is the JSON menu export document
+type MenuExport struct {
+	RestaurantID string        `json:"restaurantId"`
+	ExportedAt   string        `json:"exportedAt"`
+	Products     []MenuProduct `json:"products"`
+}
+
+// Menu import row outcomes
+const (
+	MenuImportCreated = "created"
+	MenuImportUpdated = "updated"
+	MenuImportFailed  = "failed"
+)
+
+// MenuImportRowResult reports the outcome of importing one menu row. Row is 1-based
+// and counts data rows only, not the CSV header.
+type MenuImportRowResult struct {
+	Row       int    `json:"row"`
+	Name      string `json:"name"`
+	Action    string `json:"action"`
+	ProductID string `json:"productId,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// MenuImportResult summarizes a menu import with one result per row
+type MenuImportResult struct {
+	Created int                   `json:"created"`
+	Updated int                   `json:"updated"`
+	Failed  int                   `json:"failed"`
+	Rows    []MenuImportRowResult `json:"rows"`
+}
+
+// InventoryValueItem is the stock value of one product
+type InventoryValueItem struct {
+	ProductID string  `json:"productId"`
+	Name      string  `json:"name"`
+	Price     float64 `json:"price"`
+	Stock     int32   `json:"stock"`
+	Value     float64 `json:"value"`
+}
+
+// InventoryValuation totals the value of a restaurant's stock at current prices
+type InventoryValuation struct {
+	RestaurantID string               `json:"restaurantId"`
+	TotalUnits   int64                `json:"totalUnits"`
+	TotalValue   float64              `json:"totalValue"`
+	Products     []InventoryValueItem `json:"products"`
+}
+
+// FieldError represents a single field that failed request validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// AddressValidation reports whether an address would be accepted, after
+// normalization when it was requested
+type AddressValidation struct {
+	Valid   bool         `json:"valid"`
+	Address Address      `json:"address"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
 // ErrorResponse creates a new error response
 func ErrorResponse(message string, err error) *GenericResponse {
 	errMsg := ""
@@ -45,6 +367,13 @@ func ErrorResponse(message string, err error) *GenericResponse {
 	}
 }
 
+// ErrorResponseWithCode creates a new error response carrying a machine readable error code
+func ErrorResponseWithCode(code, message string, err error) *GenericResponse {
+	response := ErrorResponse(message, err)
+	response.Code = code
+	return response
+}
+
 // SuccessResponse creates a new success response
 func SuccessResponse(message string, data interface{}) *GenericResponse {
 	return &GenericResponse{
@@ -53,3 +382,85 @@ func SuccessResponse(message string, data interface{}) *GenericResponse {
 		Data:    data,
 	}
 }
+
+// BindErrorResponse creates an error response for a failed request body bind,
+// distinguishing malformed JSON and mistyped fields from validation failures
+func BindErrorResponse(err error) *GenericResponse {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		return &GenericResponse{
+			Success: false,
+			Message: ErrMalformedJSON,
+			Error:   fmt.Sprintf("invalid JSON at byte offset %d: %s", syntaxErr.Offset, syntaxErr.Error()),
+		}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &GenericResponse{
+			Success: false,
+			Message: ErrMalformedJSON,
+			Error:   "unexpected end of JSON input",
+		}
+	case errors.Is(err, io.EOF):
+		return ErrorResponse(ErrEmptyRequestBody, nil)
+	case errors.As(err, &typeErr):
+		return &GenericResponse{
+			Success: false,
+			Message: ErrInvalidRequestFormat,
+			Error:   fmt.Sprintf("field %s must be of type %s (byte offset %d)", typeErr.Field, typeErr.Type, typeErr.Offset),
+		}
+	}
+
+	return ValidationErrorResponse(err)
+}
+
+// ValidationErrorResponse creates an error response carrying per-field validation
+// errors. Errors that are not validation errors fall back to ErrorResponse.
+func ValidationErrorResponse(err error) *GenericResponse {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return ErrorResponse(ErrInvalidRequestFormat, err)
+	}
+
+	fields := make([]FieldError, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		fields = append(fields, FieldError{
+			Field:   jsonFieldName(fe.Field()),
+			Message: fieldErrorMessage(fe),
+		})
+	}
+
+	return &GenericResponse{
+		Success: false,
+		Message: ErrInvalidRequestFormat,
+		Data:    fields,
+		Error:   fmt.Sprintf("%d field(s) failed validation", len(fields)),
+	}
+}
+
+// jsonFieldName converts a struct field name to the camelCase name used in request bodies
+func jsonFieldName(field string) string {
+	if field == "" {
+		return field
+	}
+	runes := []rune(field)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+// fieldErrorMessage returns a readable message for a failed validation tag
+func fieldErrorMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return fmt.Sprintf("must be at least %s characters", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s characters", fe.Param())
+	default:
+		return "is invalid"
+	}
+}
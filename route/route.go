@@ -1,83 +1,330 @@
 package router
 
 import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	adminPb "github.com/liju-github/CentralisedFoodbuddyMicroserviceProto/Admin"
 	orderCartPb "github.com/liju-github/CentralisedFoodbuddyMicroserviceProto/OrderCart"
 	restaurantPb "github.com/liju-github/CentralisedFoodbuddyMicroserviceProto/Restaurant"
 	user "github.com/liju-github/CentralisedFoodbuddyMicroserviceProto/User"
 	"github.com/liju-github/FoodBuddyAPIGateway/clients"
+	config "github.com/liju-github/FoodBuddyAPIGateway/configs"
 	"github.com/liju-github/FoodBuddyAPIGateway/controller"
 	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
+	"github.com/liju-github/FoodBuddyAPIGateway/utils"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 )
 
-func InitializeServiceRoutes(router *gin.Engine, Client *clients.ClientConnections) {
+// InitializeServiceRoutes registers every route and verifies the middleware chains
+// of the protected ones, returning an error if any is misconfigured
+func InitializeServiceRoutes(router *gin.Engine, Client *clients.ClientConnections) error {
+	cfg := config.LoadConfig()
+
+	// Browsers send preflight requests without credentials or tenant, so they are
+	// answered before anything else
+	router.Use(utils.CorsMiddleware(cfg.CORSAllowedOrigins))
+
+	router.Use(middleware.RequestLoggerMiddleware(logrus.StandardLogger()))
+	router.Use(middleware.LatencyMiddleware(logrus.StandardLogger(), cfg.DefaultRouteSLA, cfg.RouteSLAs))
+	// GET handlers read only from path, query or token, never from a body
+	router.Use(middleware.NoBodyMiddleware(http.MethodGet, http.MethodHead))
+	router.Use(middleware.RequestTimeoutMiddleware(logrus.StandardLogger(), cfg.RequestTimeout))
+	router.Use(middleware.ResponseFilterMiddleware(logrus.StandardLogger(), middleware.ResponseFieldRules{
+		Deny:  cfg.ResponseDenyFields,
+		Allow: cfg.ResponseAllowFields,
+	}))
+	router.Use(middleware.TimestampMiddleware(logrus.StandardLogger(), cfg.TimestampFields))
+
+	// Probes are registered ahead of the tenant and maintenance middleware, so load
+	// balancers need no tenant and keep seeing real readiness during maintenance
+	SetupHealthRoutes(router, Client, cfg)
+
+	// Cookie authenticated mutations must echo the CSRF cookie; login issues both cookies.
+	// Only the routes that never read the auth cookie are exempt, so /auth/logout,
+	// which does, stays protected.
+	if cfg.AuthCookieEnabled {
+		router.Use(middleware.CSRFMiddleware(cfg.AuthCookieName,
+			"/auth/user/login", "/auth/user/signup", "/auth/user/refresh",
+			"/auth/restaurant/login", "/auth/restaurant/signup", "/auth/restaurant/refresh",
+			"/admin/login", "/admin/refresh", "/api/public/"))
+	}
+
+	// Multi-brand deployments scope every request to one of the configured tenants
+	if len(cfg.Tenants) > 0 {
+		router.Use(middleware.TenantMiddleware(cfg.Tenants))
+	}
+
+	// Admin routes stay reachable during maintenance so operators can turn it off
+	// It starts off so the middleware self-check below can reach every route
+	maintenance := middleware.NewMaintenanceMode(false, cfg.MaintenanceRetryAfter)
+	router.Use(middleware.MaintenanceMiddleware(maintenance, "/admin", "/api/restaurants/admin", "/health"))
+
+	// The per-IP rate limit guards the login and signup routes against credential
+	// stuffing, or every route when configured globally. Admin routes are then left
+	// to a larger bucket checked after authentication, so bulk moderation is not held
+	// to the public limit. The middleware self-check below bypasses the global limiter,
+	// so its synthetic requests are not throttled.
+	limits := utils.RateLimitConfigFrom(cfg)
+	limiters := rateLimiters{
+		auth:  utils.RateLimitMiddleware(cfg, limits),
+		admin: func(c *gin.Context) { c.Next() },
+	}
+	if cfg.RateLimitGlobal {
+		globalLimiter := limiters.auth
+		router.Use(func(c *gin.Context) {
+			if isSelfCheck(c.Request) || isAdminRoute(c.FullPath()) {
+				c.Next()
+				return
+			}
+			globalLimiter(c)
+		})
+		// Auth routes are already covered, and must not count twice
+		limiters.auth = func(c *gin.Context) { c.Next() }
+
+		adminLimits := limits
+		adminLimits.Requests = cfg.RateLimitAdminRequests
+		adminLimits.Scope = middleware.RoleAdmin
+		limiters.admin = utils.RateLimitMiddleware(cfg, adminLimits)
+	}
+
+	// State shared between gateway replicas lives in Redis, reached through one client
+	var redisClient *utils.RedisClient
+	sharedRedis := func() (*utils.RedisClient, error) {
+		if redisClient == nil {
+			client, err := utils.NewRedisClient(cfg.RedisURL, cfg.RedisTimeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid Redis URL: %w", err)
+			}
+			redisClient = client
+		}
+		return redisClient, nil
+	}
+
+	// Each user may export their data DataExportLimit times per window, counting only
+	// exports that succeed
+	exportLimits := utils.RateLimitConfig{
+		Requests:  cfg.DataExportLimit,
+		Window:    cfg.DataExportWindow,
+		Scope:     "dataexport",
+		WarnRatio: cfg.RateLimitWarnRatio,
+	}
+	limiters.dataExport = utils.EntityRateLimitMiddleware(exportLimits)
+	if cfg.RateLimitBackend == utils.RateLimitBackendRedis {
+		client, err := sharedRedis()
+		if err != nil {
+			return fmt.Errorf("invalid rate limit configuration: %w", err)
+		}
+		limiters.dataExport = utils.RedisEntityRateLimitMiddleware(client, exportLimits)
+	}
+
 	userClient := user.NewUserServiceClient(Client.ConnUser)
-	userController := controller.NewUserController(userClient)
-	SetupUserRoutes(router, userController)
+	versionGuard := clientVersionGuard(cfg)
+	// Admin mutations can opt in to nonce based replay protection
+	replayGuard := func(c *gin.Context) { c.Next() }
+	if cfg.AdminReplayProtection {
+		replayGuard = middleware.ReplayProtectionMiddleware(middleware.NewNonceTracker())
+	}
+
+	// Every controller that issues tokens signs them with the same service, and
+	// logged out tokens are rejected until they expire
+	keys, err := middleware.KeyRingFromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid JWT key configuration: %w", err)
+	}
+	tokens := middleware.NewTokenService(keys, cfg.AccessTokenTTL)
+	switch cfg.TokenBlacklistBackend {
+	case "memory":
+		middleware.UseTokenBlacklist(middleware.NewInMemoryTokenBlacklist(time.Minute))
+	case "redis":
+		client, err := sharedRedis()
+		if err != nil {
+			return fmt.Errorf("invalid token blacklist configuration: %w", err)
+		}
+		middleware.UseTokenBlacklist(utils.NewRedisTokenBlacklist(client, logrus.StandardLogger()))
+	default:
+		return fmt.Errorf("unknown token blacklist backend %q", cfg.TokenBlacklistBackend)
+	}
+
+	// Both signup flows share one deny-list, and a configured file that cannot be read
+	// fails startup rather than letting blocked domains sign up
+	blockedEmailDomains, err := utils.LoadEmailDomainDenyList(cfg.BlockedEmailDomains, cfg.BlockedEmailDomainsFile)
+	if err != nil {
+		return fmt.Errorf("invalid blocked email domain configuration: %w", err)
+	}
+
+	userController := controller.NewUserController(cfg, userClient, tokens, blockedEmailDomains)
+	SetupUserRoutes(router, userController, versionGuard, replayGuard, limiters)
 
 	restaurantClient := restaurantPb.NewRestaurantServiceClient(Client.ConnRestaurant)
-	restaurantController := controller.NewRestaurantController(restaurantClient)
-	SetupRestaurantRoutes(router, restaurantController)
+	listingCache := utils.NewCache(cfg.PublicListingCacheTTL)
+	restaurantController := controller.NewRestaurantController(cfg, restaurantClient, tokens, blockedEmailDomains, listingCache, utils.NewCache(cfg.InventoryValueCacheTTL), utils.NewCatalogVersion())
+	SetupRestaurantRoutes(router, restaurantController, replayGuard, limiters)
+
+	// Favorites need a store shared by all replicas, and answer 501 without one
+	var favoritesStore utils.FavoritesStore
+	switch cfg.FavoritesBackend {
+	case "":
+	case "redis":
+		client, err := sharedRedis()
+		if err != nil {
+			return fmt.Errorf("invalid favorites configuration: %w", err)
+		}
+		favoritesStore = utils.NewRedisFavoritesStore(client)
+	default:
+		return fmt.Errorf("unknown favorites backend %q", cfg.FavoritesBackend)
+	}
+	favoritesController := controller.NewFavoritesController(cfg, logrus.StandardLogger(), restaurantClient, favoritesStore)
+	SetupFavoritesRoutes(router, favoritesController, versionGuard, userClient)
 
 	orderCartClient := orderCartPb.NewOrderCartServiceClient(Client.ConnOrderCart)
+	notifier, err := utils.NewWebhookNotifier(cfg.RestaurantWebhooks, cfg.RestaurantWebhookSecrets, cfg.WebhookTimeout, cfg.WebhookMaxRetries)
+	if err != nil {
+		return fmt.Errorf("invalid webhook configuration: %w", err)
+	}
 	orderCartController := controller.NewOrderCartController(
+		cfg,
 		orderCartClient,
 		userClient,
 		restaurantClient,
+		notifier,
+		utils.NewCache(cfg.TrendingCacheTTL),
+		utils.NewCache(cfg.RestaurantNameCacheTTL),
+		utils.NewCache(cfg.RestaurantMetricsCacheTTL),
+		utils.NewCache(cfg.OrderCountsCacheTTL),
+		utils.NewInMemoryOrderCodeStore(cfg.OrderCodeTTL),
 	)
-	SetupOrderCartRoutes(router, orderCartController)
+	SetupOrderCartRoutes(router, orderCartController, versionGuard, userClient, limiters)
 
 	adminClient := adminPb.NewAdminServiceClient(Client.ConnAdmin)
-	adminController := controller.NewAdminController(adminClient)
-	SetUpAdminAuth(router, adminController)
+	adminController := controller.NewAdminController(cfg, adminClient, tokens, maintenance)
+	SetUpAdminAuth(router, adminController, replayGuard, limiters)
+	SetupSessionRoutes(router, controller.NewAuthController(cfg, tokens))
+
+	if err := VerifyMiddlewareChains(router, cfg); err != nil {
+		return err
+	}
+	maintenance.SetEnabled(cfg.MaintenanceMode)
+	return nil
+}
+
+// rateLimiters are the rate limiters registered on individual routes. auth guards
+// the login and signup routes, and admin runs after AdminAuthMiddleware on admin
+// routes. Either is a pass-through when not in use. dataExport limits each user's
+// data exports.
+type rateLimiters struct {
+	auth       gin.HandlerFunc
+	admin      gin.HandlerFunc
+	dataExport gin.HandlerFunc
+}
+
+// usersMeSunset is the date GET /api/users/me is removed, in favor of GET /api/users/profile
+const usersMeSunset = "2027-04-30"
+
+// isAdminRoute reports whether the route at path requires the admin role, and so is
+// rate limited by rateLimiters.admin rather than the global limiter
+func isAdminRoute(path string) bool {
+	role, ok := requiredRole(path)
+	return ok && role == middleware.RoleAdmin
 }
 
-func SetUpAdminAuth(router *gin.Engine, adminController *controller.AdminController) {
-	router.POST("/admin/login", adminController.AdminLogin)
+// clientVersionGuard returns the middleware that turns away outdated client apps on
+// client-facing routes. Nothing is enforced unless a minimum client version is configured.
+func clientVersionGuard(cfg config.Config) gin.HandlerFunc {
+	return middleware.ClientVersionMiddleware(cfg.MinClientVersion, cfg.ClientPlatformMinVersions)
 }
 
-func SetupUserRoutes(router *gin.Engine, userController *controller.UserController) {
+func SetUpAdminAuth(router *gin.Engine, adminController *controller.AdminController, replayGuard gin.HandlerFunc, limiters rateLimiters) {
+	router.POST("/admin/login", limiters.auth, adminController.AdminLogin)
+	router.POST("/admin/refresh", adminController.RefreshToken)
+
+	admin := router.Group("/admin")
+	admin.Use(middleware.JWTAuthMiddleware(), middleware.AdminAuthMiddleware(), limiters.admin)
+	{
+		admin.GET("/maintenance", adminController.GetMaintenanceMode) // no parameters
+		admin.PUT("/maintenance", replayGuard, adminController.SetMaintenanceMode)
+		admin.GET("/config", adminController.GetConfig)    // no parameters
+		admin.GET("/metrics", gin.WrapH(expvar.Handler())) // no parameters
+	}
+
+	// Any authenticated caller may inspect its own token
+	token := router.Group("/api/token")
+	token.Use(middleware.JWTAuthMiddleware())
+	{
+		token.GET("/info", controller.TokenInfo) // claims: token
+	}
+}
+
+func SetupHealthRoutes(router *gin.Engine, Client *clients.ClientConnections, cfg config.Config) {
+	conns := make(map[string]grpc.ClientConnInterface)
+	for name, conn := range Client.Services() {
+		conns[name] = conn
+	}
+	healthController := controller.NewHealthController(conns, Client.BreakerStates, cfg.ReadyRequiredServices, cfg.HealthCheckTimeout)
+
+	router.GET("/health", healthController.Health) // no parameters
+	router.GET("/ready", healthController.Ready)   // no parameters
+}
+
+func SetupSessionRoutes(router *gin.Engine, authController *controller.AuthController) {
+	// Any authenticated caller may end its own session
+	router.POST("/auth/logout", middleware.JWTAuthMiddleware(), authController.Logout)
+}
+
+func SetupUserRoutes(router *gin.Engine, userController *controller.UserController, versionGuard, replayGuard gin.HandlerFunc, limiters rateLimiters) {
 	auth := router.Group("/auth/user")
 	{
-		auth.POST("/signup", userController.Signup)
-		auth.POST("/login", userController.Login)
+		auth.POST("/signup", limiters.auth, userController.Signup)
+		auth.POST("/login", limiters.auth, userController.Login)
+		auth.POST("/refresh", userController.RefreshToken)
 		auth.POST("/verify-email", userController.VerifyEmail)
 	}
 
 	protected := router.Group("/api/users")
-	protected.Use(middleware.JWTAuthMiddleware(), middleware.UserAuthMiddleware(), middleware.UserBanCheckMiddleware(userController.GetUserClient()))
+	protected.Use(versionGuard, middleware.JWTAuthMiddleware(), middleware.UserAuthMiddleware(), middleware.UserBanCheckMiddleware(userController.GetUserClient()))
 	{
+		// /me predates /profile, which returns the same user and supports ?fields=
+		protected.GET("/me", middleware.DeprecationMiddleware(usersMeSunset), userController.GetUserByToken) // user ID: token
+
 		profile := protected.Group("/profile")
 		{
-			profile.GET("", userController.GetProfile)
+			profile.GET("", userController.GetProfile) // user ID: token
 			profile.PUT("/update", userController.UpdateProfile)
 		}
 
 		address := protected.Group("/address")
 		{
 			address.POST("/add", userController.AddAddress)
-			address.GET("/list", userController.GetAddresses)
+			address.POST("/validate", userController.ValidateAddress) // normalize: query
+			address.GET("/list", userController.GetAddresses)         // user ID: token
 			address.PUT("/update", userController.EditAddress)
-			address.DELETE("/remove/:addressId", userController.DeleteAddress)
+			address.DELETE("/remove/:addressId", middleware.NoBodyMiddleware(http.MethodDelete), userController.DeleteAddress) // addressId: path, user ID: token
 		}
 	}
 
 	admin := router.Group("/admin/users")
-	admin.Use(middleware.JWTAuthMiddleware(), middleware.AdminAuthMiddleware())
+	admin.Use(middleware.JWTAuthMiddleware(), middleware.AdminAuthMiddleware(), limiters.admin)
 	{
-		admin.GET("/list", userController.GetAllUsers)
-		admin.POST("/ban", userController.BanUser)
-		admin.POST("/unban", userController.UnBanUser)
-		admin.GET("/ban/status", userController.CheckBan)
+		admin.GET("/list", userController.GetAllUsers) // no parameters
+		admin.POST("/ban", replayGuard, userController.BanUser)
+		admin.POST("/unban", replayGuard, userController.UnBanUser)
+		admin.POST("/ban/bulk", replayGuard, userController.BulkBanUsers)
+		admin.POST("/unban/bulk", replayGuard, userController.BulkUnbanUsers)
+		admin.GET("/ban/status", userController.CheckBan) // userId: query
+		admin.POST("/:userId/impersonate", replayGuard, userController.ImpersonateUser)
 	}
 }
 
-func SetupRestaurantRoutes(router *gin.Engine, restaurantController *controller.RestaurantController) {
+func SetupRestaurantRoutes(router *gin.Engine, restaurantController *controller.RestaurantController, replayGuard gin.HandlerFunc, limiters rateLimiters) {
 	auth := router.Group("/auth/restaurant")
 	{
-		auth.POST("/signup", restaurantController.RestaurantSignup)
-		auth.POST("/login", restaurantController.RestaurantLogin)
+		auth.POST("/signup", limiters.auth, restaurantController.RestaurantSignup)
+		auth.POST("/login", limiters.auth, restaurantController.RestaurantLogin)
+		auth.POST("/refresh", restaurantController.RefreshToken)
 	}
 
 	protected := router.Group("/api/restaurants")
@@ -90,7 +337,10 @@ func SetupRestaurantRoutes(router *gin.Engine, restaurantController *controller.
 
 			products := restaurant.Group("/products")
 			{
+				products.GET("", restaurantController.GetInventory)          // availability, sort: query, restaurant ID: token
+				products.GET("/export", restaurantController.ExportProducts) // format: query, restaurant ID: token
 				products.POST("/add", restaurantController.AddProduct)
+				products.POST("/import", restaurantController.ImportProducts) // format, mode: query
 				products.PUT("/update", restaurantController.EditProduct)
 				products.DELETE("/remove", restaurantController.DeleteProductByID)
 				products.PUT("/stock/increment", restaurantController.IncrementProductStock)
@@ -99,50 +349,121 @@ func SetupRestaurantRoutes(router *gin.Engine, restaurantController *controller.
 		}
 
 		admin := protected.Group("/admin")
-		admin.Use(middleware.AdminAuthMiddleware())
+		admin.Use(middleware.AdminAuthMiddleware(), limiters.admin)
 		{
-			admin.POST("/ban", restaurantController.BanRestaurant) 
-			admin.POST("/unban", restaurantController.UnbanRestaurant)
+			admin.POST("/ban", replayGuard, restaurantController.BanRestaurant)
+			admin.POST("/unban", replayGuard, restaurantController.UnbanRestaurant)
+
+			// Admins may manage any restaurant's products, restaurants only their own
+			adminProducts := admin.Group("/products")
+			{
+				adminProducts.PUT("/update", restaurantController.AdminEditProduct)
+				adminProducts.DELETE("/remove", restaurantController.AdminDeleteProductByID)
+				adminProducts.PUT("/stock/increment", restaurantController.AdminIncrementProductStock)
+				adminProducts.PUT("/stock/decrement", restaurantController.AdminDecrementProductStock)
+			}
 		}
 	}
 
+	inventory := router.Group("/api/restaurant/inventory")
+	inventory.Use(middleware.JWTAuthMiddleware(), middleware.RestaurantAuthMiddleware())
+	{
+		inventory.GET("/value", restaurantController.GetInventoryValue) // restaurant ID: token
+	}
+
 	public := router.Group("/api/public/restaurants")
 	{
-		public.GET("/list", restaurantController.GetAllRestaurantWithProducts)
-		public.GET("/products/list", restaurantController.GetRestaurantProductsByID)
-		public.GET("/products/all", restaurantController.GetAllProducts)
-		public.GET("/products/details", restaurantController.GetProductByID)
-		public.GET("/products/stock", restaurantController.GetStockByProductID)
-		public.GET("/lookup", restaurantController.GetRestaurantIDviaProductID)
+		public.GET("/list", restaurantController.GetAllRestaurantWithProducts)       // no parameters
+		public.GET("/products/list", restaurantController.GetRestaurantProductsByID) // restaurantId: query
+		public.GET("/products/all", restaurantController.GetAllProducts)             // no parameters
+		public.GET("/products/details", restaurantController.GetProductByID)         // productId: query
+		public.GET("/products/stock", restaurantController.GetStockByProductID)      // productId: query
+		public.GET("/products/version", restaurantController.GetCatalogVersion)      // no parameters
+		public.GET("/lookup", restaurantController.GetRestaurantIDviaProductID)      // productId: query
+		public.POST("/status", restaurantController.GetRestaurantStatuses)
 	}
 }
 
-func SetupOrderCartRoutes(router *gin.Engine, orderCartController *controller.OrderCartController) {
+func SetupFavoritesRoutes(router *gin.Engine, favoritesController *controller.FavoritesController, versionGuard gin.HandlerFunc, userClient user.UserServiceClient) {
+	favorites := router.Group("/api/users/favorites")
+	favorites.Use(versionGuard, middleware.JWTAuthMiddleware(), middleware.UserAuthMiddleware(), middleware.UserBanCheckMiddleware(userClient))
+	{
+		favorites.POST("", favoritesController.AddFavorite)
+		favorites.GET("", favoritesController.GetFavorites)                                                                    // user ID: token
+		favorites.DELETE("/:restaurantId", middleware.NoBodyMiddleware(http.MethodDelete), favoritesController.RemoveFavorite) // restaurantId: path, user ID: token
+	}
+}
+
+func SetupOrderCartRoutes(router *gin.Engine, orderCartController *controller.OrderCartController, versionGuard gin.HandlerFunc, userClient user.UserServiceClient, limiters rateLimiters) {
 	cart := router.Group("/api/cart")
-	cart.Use(middleware.JWTAuthMiddleware(), middleware.UserAuthMiddleware())
+	cart.Use(versionGuard, middleware.JWTAuthMiddleware(), middleware.UserAuthMiddleware())
 	{
 		cart.POST("/add", orderCartController.AddProductToCart)
-		cart.GET("/items", orderCartController.GetCartItems)
-		cart.GET("/list", orderCartController.GetAllCarts)
+		cart.GET("/items", orderCartController.GetCartItems)     // restaurantId: query, user ID: token
+		cart.GET("/list", orderCartController.GetAllCarts)       // user ID: token
+		cart.GET("/summary", orderCartController.GetCartSummary) // user ID: token
+		cart.GET("/count", orderCartController.GetCartCount)     // byRestaurant: query, user ID: token
 		cart.POST("/increment", orderCartController.IncrementProductQuantity)
 		cart.POST("/decrement", orderCartController.DecrementProductQuantity)
 		cart.POST("/remove", orderCartController.RemoveProductFromCart)
 		cart.POST("/clear", orderCartController.ClearCart)
+		cart.POST("/merge", orderCartController.MergeCart) // guestCartId: body, user ID: token
+		cart.POST("/validate", orderCartController.ValidateCart)
 	}
 
 	userOrder := router.Group("/api/orders")
-	userOrder.Use(middleware.JWTAuthMiddleware(), middleware.UserAuthMiddleware())
+	userOrder.Use(versionGuard, middleware.JWTAuthMiddleware(), middleware.UserAuthMiddleware())
 	{
 		userOrder.POST("/place", orderCartController.PlaceOrderByRestID)
-		userOrder.GET("/list", orderCartController.GetOrderDetailsAll)
-		userOrder.GET("/details", orderCartController.GetOrderDetailsByID)
+		userOrder.GET("/list", orderCartController.GetOrderDetailsAll)     // status: query, user ID: token
+		userOrder.GET("/details", orderCartController.GetOrderDetailsByID) // orderId: query, user ID: token
+		userOrder.GET("/active", orderCartController.GetActiveOrder)       // user ID: token
+		userOrder.GET("/delivery-fee", orderCartController.GetDeliveryFee) // restaurantId, addressId: query, user ID: token
 		userOrder.POST("/cancel", orderCartController.CancelOrder)
+		userOrder.GET("/:orderId/eta", orderCartController.GetOrderETA) // orderId: path, user ID: token
+		userOrder.PUT("/:orderId/address", orderCartController.UpdateOrderAddress)
+	}
+
+	// Support reads order codes to users over the phone, so admins may resolve any code
+	orderByCode := router.Group("/api/orders/by-code")
+	orderByCode.Use(middleware.JWTAuthMiddleware(), middleware.RequireAnyRole(middleware.RoleUser, middleware.RoleAdmin))
+	{
+		orderByCode.GET("", orderCartController.GetOrderByCode) // code: query, user ID: token
 	}
 
+	// Order mutations of one restaurant are serialized, different restaurants run in parallel
+	restaurantOrderLocks := middleware.SerializePerEntityMiddleware(middleware.NewKeyedMutex())
+
 	restaurantOrder := router.Group("/api/restaurant/orders")
 	restaurantOrder.Use(middleware.JWTAuthMiddleware(), middleware.RestaurantAuthMiddleware())
 	{
-		restaurantOrder.GET("/list", orderCartController.GetRestaurantOrders)
-		restaurantOrder.POST("/confirm", orderCartController.ConfirmOrder)
+		restaurantOrder.GET("/list", orderCartController.GetRestaurantOrders)           // status: query, restaurant ID: token
+		restaurantOrder.GET("/pending", orderCartController.GetPendingRestaurantOrders) // restaurant ID: token
+		restaurantOrder.POST("/confirm", restaurantOrderLocks, orderCartController.ConfirmOrder)
+		restaurantOrder.GET("/metrics", orderCartController.GetRestaurantMetrics)    // restaurant ID: token
+		restaurantOrder.GET("/counts", orderCartController.GetRestaurantOrderCounts) // restaurant ID: token
+	}
+
+	restaurantEarnings := router.Group("/api/restaurant/earnings")
+	restaurantEarnings.Use(middleware.JWTAuthMiddleware(), middleware.RestaurantAuthMiddleware())
+	{
+		restaurantEarnings.GET("", orderCartController.GetRestaurantEarnings) // from, to: query, restaurant ID: token
+	}
+
+	publicProducts := router.Group("/api/public/products")
+	{
+		publicProducts.GET("/trending", orderCartController.GetTrendingProducts) // limit: query
+	}
+
+	adminRestaurants := router.Group("/admin/restaurants")
+	adminRestaurants.Use(middleware.JWTAuthMiddleware(), middleware.AdminAuthMiddleware(), limiters.admin)
+	{
+		adminRestaurants.GET("/:restaurantId/impact", orderCartController.GetRestaurantImpact) // restaurantId: path
+	}
+
+	userData := router.Group("/api/users")
+	userData.Use(versionGuard, middleware.JWTAuthMiddleware(), middleware.UserAuthMiddleware(), middleware.UserBanCheckMiddleware(userClient))
+	{
+		userData.GET("/data-export", limiters.dataExport, orderCartController.ExportUserData) // user ID: token
 	}
 }
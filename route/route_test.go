@@ -0,0 +1,159 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liju-github/FoodBuddyAPIGateway/clients"
+	config "github.com/liju-github/FoodBuddyAPIGateway/configs"
+)
+
+// newTestRouter registers every route with the given environment. Backends are
+// never reached: gRPC connections are only dialed on first use.
+func newTestRouter(t *testing.T, env map[string]string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	for name, value := range env {
+		t.Setenv(name, value)
+	}
+
+	cfg := config.LoadConfig()
+	conns, err := clients.InitClients(&cfg)
+	if err != nil {
+		t.Fatalf("InitClients: %v", err)
+	}
+	t.Cleanup(conns.Close)
+
+	router := gin.New()
+	if err := InitializeServiceRoutes(router, conns); err != nil {
+		t.Fatalf("InitializeServiceRoutes: %v", err)
+	}
+	return router
+}
+
+func postLogin(router *gin.Engine, path, remoteAddr string) int {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = remoteAddr
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder.Code
+}
+
+func TestLoginRateLimit(t *testing.T) {
+	const limit = 3
+	router := newTestRouter(t, map[string]string{
+		"RATELIMITBACKEND":  "memory",
+		"RATELIMITREQUESTS": strconv.Itoa(limit),
+		"RATELIMITGLOBAL":   "false",
+	})
+
+	for i := 0; i < limit; i++ {
+		if code := postLogin(router, "/auth/user/login", "203.0.113.1:1234"); code == http.StatusTooManyRequests {
+			t.Fatalf("attempt %d: got 429 within the limit", i+1)
+		}
+	}
+	if code := postLogin(router, "/auth/user/login", "203.0.113.1:1234"); code != http.StatusTooManyRequests {
+		t.Fatalf("attempt %d: got %d, want 429", limit+1, code)
+	}
+
+	// Login routes share one counter, and other IPs are unaffected
+	if code := postLogin(router, "/admin/login", "203.0.113.1:1234"); code != http.StatusTooManyRequests {
+		t.Errorf("admin login from the same IP: got %d, want 429", code)
+	}
+	if code := postLogin(router, "/auth/user/login", "203.0.113.2:1234"); code == http.StatusTooManyRequests {
+		t.Errorf("login from another IP: got 429")
+	}
+}
+
+func TestGlobalRateLimitSkipsSelfCheck(t *testing.T) {
+	// The self-check sends far more than one request, and must still pass
+	router := newTestRouter(t, map[string]string{
+		"RATELIMITBACKEND":  "memory",
+		"RATELIMITREQUESTS": "1",
+		"RATELIMITGLOBAL":   "true",
+	})
+
+	if code := postLogin(router, "/auth/user/login", "203.0.113.1:1234"); code == http.StatusTooManyRequests {
+		t.Fatalf("first request: got 429")
+	}
+	if code := postLogin(router, "/auth/user/login", "203.0.113.1:1234"); code != http.StatusTooManyRequests {
+		t.Fatalf("second request: got %d, want 429", code)
+	}
+}
+
+func TestCSRFProtectsLogout(t *testing.T) {
+	router := newTestRouter(t, map[string]string{
+		"AUTHCOOKIEENABLED": "true",
+		"AUTHCOOKIENAME":    "foodbuddy_token",
+	})
+
+	post := func(path string) int {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "foodbuddy_token", Value: "session"})
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	// A cross-site request carries the auth cookie but cannot echo the CSRF cookie
+	if code := post("/auth/logout"); code != http.StatusForbidden {
+		t.Errorf("logout without a CSRF token: got %d, want 403", code)
+	}
+	if code := post("/auth/user/login"); code == http.StatusForbidden {
+		t.Errorf("login with a stale auth cookie: got 403")
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	router := newTestRouter(t, map[string]string{
+		"CORSALLOWEDORIGINS": "https://app.example.com",
+	})
+
+	preflight := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, "/api/users/me", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	allowed := preflight("https://app.example.com")
+	if allowed.Code != http.StatusNoContent {
+		t.Fatalf("preflight: got %d, want 204", allowed.Code)
+	}
+	if got := allowed.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("allowed origin echoed as %q", got)
+	}
+	if got := allowed.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("credentials for an allowed origin: %q, want true", got)
+	}
+
+	other := preflight("https://evil.example.com")
+	if got := other.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("unlisted origin allowed as %q", got)
+	}
+}
+
+func TestUnreadableEmailDenyListFailsStartup(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("BLOCKEDEMAILDOMAINSFILE", filepath.Join(t.TempDir(), "missing.txt"))
+
+	cfg := config.LoadConfig()
+	conns, err := clients.InitClients(&cfg)
+	if err != nil {
+		t.Fatalf("InitClients: %v", err)
+	}
+	t.Cleanup(conns.Close)
+
+	if err := InitializeServiceRoutes(gin.New(), conns); err == nil {
+		t.Fatal("routes registered with an unreadable deny-list file")
+	}
+}
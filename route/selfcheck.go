@@ -0,0 +1,165 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	config "github.com/liju-github/FoodBuddyAPIGateway/configs"
+	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
+)
+
+// routeAuthRule declares the role required for routes under a path prefix.
+// An empty role marks the prefix as public and anyRole as open to every
+// authenticated caller. The longest matching prefix wins.
+type routeAuthRule struct {
+	prefix string
+	role   string
+}
+
+// routeAuthRules must cover every registered route, so new routes fail the
+// startup check until their access level is declared here
+var routeAuthRules = []routeAuthRule{
+	{prefix: "/auth/", role: ""},
+	{prefix: "/auth/logout", role: anyRole},
+	{prefix: "/health", role: ""},
+	{prefix: "/ready", role: ""},
+	{prefix: "/admin/login", role: ""},
+	{prefix: "/admin/refresh", role: ""},
+	{prefix: "/api/public/", role: ""},
+	{prefix: "/admin/", role: middleware.RoleAdmin},
+	{prefix: "/api/restaurants/admin/", role: middleware.RoleAdmin},
+	{prefix: "/api/restaurants/", role: middleware.RoleRestaurant},
+	{prefix: "/api/restaurant/", role: middleware.RoleRestaurant},
+	{prefix: "/api/cart/", role: middleware.RoleUser},
+	{prefix: "/api/orders/", role: middleware.RoleUser},
+	{prefix: "/api/orders/by-code", role: anyRole},
+	{prefix: "/api/users/", role: middleware.RoleUser},
+	{prefix: "/api/token/", role: anyRole},
+}
+
+// anyRole marks routes that need a valid token but no particular role
+const anyRole = "*"
+
+// VerifyMiddlewareChains sends synthetic requests to every protected route and checks
+// that JWTAuthMiddleware runs before the role middleware: a request without a token
+// must get 401 and a token with the wrong role must get 403. Both are rejected before
+// any handler or backend call runs. Must be called while maintenance mode is off.
+func VerifyMiddlewareChains(router *gin.Engine, cfg config.Config) error {
+	keys, err := middleware.KeyRingFromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid JWT key configuration: %w", err)
+	}
+
+	headers := http.Header{}
+	if cfg.MinClientVersion != "" {
+		headers.Set(middleware.ClientVersionHeader, cfg.MinClientVersion)
+	}
+	if len(cfg.Tenants) > 0 {
+		headers.Set(middleware.TenantHeader, cfg.Tenants[0])
+	}
+
+	var problems []string
+	for _, route := range router.Routes() {
+		role, ok := requiredRole(route.Path)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s %s: no auth rule declared", route.Method, route.Path))
+			continue
+		}
+		if role == "" {
+			continue
+		}
+
+		path := fillPathParams(route.Path)
+
+		if code := serveSelfCheck(router, route.Method, path, "", headers); code != http.StatusUnauthorized {
+			problems = append(problems, fmt.Sprintf("%s %s: expected 401 without a token, got %d", route.Method, route.Path, code))
+		}
+		if role == anyRole {
+			continue
+		}
+
+		wrongRole := middleware.RoleUser
+		if role == middleware.RoleUser {
+			wrongRole = middleware.RoleAdmin
+		}
+		token, err := selfCheckToken(keys, wrongRole)
+		if err != nil {
+			return fmt.Errorf("failed to sign self-check token: %w", err)
+		}
+		if code := serveSelfCheck(router, route.Method, path, token, headers); code != http.StatusForbidden {
+			problems = append(problems, fmt.Sprintf("%s %s: expected 403 for a token with role %s, got %d", route.Method, route.Path, wrongRole, code))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("middleware chain misconfigured:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// requiredRole returns the role of the longest rule prefix matching path
+func requiredRole(path string) (string, bool) {
+	match := -1
+	for i, rule := range routeAuthRules {
+		if strings.HasPrefix(path, rule.prefix) && (match < 0 || len(rule.prefix) > len(routeAuthRules[match].prefix)) {
+			match = i
+		}
+	}
+	if match < 0 {
+		return "", false
+	}
+	return routeAuthRules[match].role, true
+}
+
+// fillPathParams replaces :param and *param segments with a placeholder value
+func fillPathParams(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "selfcheck"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// selfCheckKey marks the context of synthetic self-check requests
+type selfCheckKey struct{}
+
+// isSelfCheck reports whether req was sent by VerifyMiddlewareChains. Clients cannot
+// set the marker, as it lives in the request context rather than the request.
+func isSelfCheck(req *http.Request) bool {
+	marked, _ := req.Context().Value(selfCheckKey{}).(bool)
+	return marked
+}
+
+func serveSelfCheck(router *gin.Engine, method, path, token string, headers http.Header) int {
+	req := httptest.NewRequest(method, path, nil)
+	req = req.WithContext(context.WithValue(req.Context(), selfCheckKey{}, true))
+	for name, values := range headers {
+		req.Header[name] = values
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder.Code
+}
+
+func selfCheckToken(keys *middleware.KeyRing, role string) (string, error) {
+	now := time.Now()
+	return keys.Sign(jwt.MapClaims{
+		"id":           "selfcheck",
+		"role":         role,
+		"exp":          now.Add(time.Minute).Unix(),
+		"iat":          now.Unix(),
+		"sessionStart": now.Unix(),
+	})
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"net/url"
+	"sync"
+	"time"
+)
+
+// maxCacheEntries bounds each ResponseCache, so keys derived from request input
+// cannot grow gateway memory without limit
+const maxCacheEntries = 1000
+
+// Cache stores backend responses between requests. Controllers invalidate it
+// whenever they mutate data that cached responses are built from.
+type Cache interface {
+	Get(key string) (interface{}, bool)
+	Set(key string, value interface{})
+	Invalidate()
+}
+
+// NewCache returns a ResponseCache for a positive ttl, and a no-op cache when caching is disabled
+func NewCache(ttl time.Duration) Cache {
+	if ttl <= 0 {
+		return NoopCache{}
+	}
+	return NewResponseCache(ttl)
+}
+
+// NoopCache is a Cache that never stores anything
+type NoopCache struct{}
+
+func (NoopCache) Get(string) (interface{}, bool) { return nil, false }
+func (NoopCache) Set(string, interface{})        {}
+func (NoopCache) Invalidate()                    {}
+
+type cacheEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// ResponseCache is a small in-memory TTL cache for backend responses, holding at
+// most maxCacheEntries entries. Expired entries are dropped when read, and when a
+// full cache needs room.
+type ResponseCache struct {
+	mutex   sync.RWMutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+}
+
+// NewResponseCache creates a cache whose entries live for ttl
+func NewResponseCache(ttl time.Duration) *ResponseCache {
+	return &ResponseCache{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// Get returns the cached value for key if it exists and has not expired
+func (rc *ResponseCache) Get(key string) (interface{}, bool) {
+	rc.mutex.RLock()
+	entry, ok := rc.entries[key]
+	rc.mutex.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	if time.Now().After(entry.expiresAt) {
+		rc.mutex.Lock()
+		// The entry may have been refreshed since it was read
+		if current, ok := rc.entries[key]; ok && time.Now().After(current.expiresAt) {
+			delete(rc.entries, key)
+		}
+		rc.mutex.Unlock()
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// Set stores value under key for the cache TTL
+func (rc *ResponseCache) Set(key string, value interface{}) {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	if _, exists := rc.entries[key]; !exists && len(rc.entries) >= maxCacheEntries {
+		rc.evict()
+	}
+	rc.entries[key] = cacheEntry{
+		value:     value,
+		expiresAt: time.Now().Add(rc.ttl),
+	}
+}
+
+// evict makes room for one entry by dropping every expired entry, or the entry
+// closest to expiry when none has expired. Must be called with the lock held.
+func (rc *ResponseCache) evict() {
+	now := time.Now()
+	// "" is a valid key, so whether an oldest entry was seen is tracked separately
+	var (
+		found     bool
+		oldestKey string
+		oldest    time.Time
+	)
+	for key, entry := range rc.entries {
+		if now.After(entry.expiresAt) {
+			delete(rc.entries, key)
+			continue
+		}
+		if !found || entry.expiresAt.Before(oldest) {
+			found, oldestKey, oldest = true, key, entry.expiresAt
+		}
+	}
+	if found && len(rc.entries) >= maxCacheEntries {
+		delete(rc.entries, oldestKey)
+	}
+}
+
+// Invalidate removes every entry from the cache
+func (rc *ResponseCache) Invalidate() {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	rc.entries = make(map[string]cacheEntry)
+}
+
+// CacheKey builds a cache key from the given query parameters only, so unrelated
+// parameters sent by clients cannot create extra entries
+func CacheKey(query url.Values, params ...string) string {
+	keyed := url.Values{}
+	for _, param := range params {
+		if values, ok := query[param]; ok {
+			keyed[param] = values
+		}
+	}
+	return keyed.Encode()
+}
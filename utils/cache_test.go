@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestResponseCacheEvictsEmptyKey(t *testing.T) {
+	cache := NewResponseCache(time.Hour)
+	now := time.Now()
+
+	// The empty key, which requests without cached parameters map to, expires first
+	cache.entries[""] = cacheEntry{value: "empty", expiresAt: now.Add(time.Minute)}
+	for i := 1; i < maxCacheEntries; i++ {
+		cache.entries[strconv.Itoa(i)] = cacheEntry{value: i, expiresAt: now.Add(time.Hour)}
+	}
+
+	cache.Set("new", "value")
+
+	if len(cache.entries) != maxCacheEntries {
+		t.Fatalf("cache holds %d entries, want %d", len(cache.entries), maxCacheEntries)
+	}
+	if _, ok := cache.Get(""); ok {
+		t.Error("entry closest to expiry was not evicted")
+	}
+	for _, key := range []string{"1", strconv.Itoa(maxCacheEntries - 1), "new"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("entry %q was evicted", key)
+		}
+	}
+}
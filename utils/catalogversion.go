@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// CatalogVersion tracks when the product catalog last changed, so clients can tell
+// whether to refetch it. The restaurant service keeps no such timestamp, so the
+// gateway bumps the version whenever it applies a product change. It starts at the
+// gateway's start time, which makes clients refetch once after a restart rather
+// than miss changes made meanwhile. It is safe for concurrent use.
+type CatalogVersion struct {
+	mutex      sync.Mutex
+	modifiedAt time.Time
+}
+
+// NewCatalogVersion creates a version marking the catalog as modified now
+func NewCatalogVersion() *CatalogVersion {
+	return &CatalogVersion{modifiedAt: time.Now().Truncate(time.Millisecond)}
+}
+
+// Bump records a catalog change. The modification time always moves forward, so
+// two changes in the same millisecond still produce different versions.
+func (v *CatalogVersion) Bump() {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	now := time.Now().Truncate(time.Millisecond)
+	if !now.After(v.modifiedAt) {
+		now = v.modifiedAt.Add(time.Millisecond)
+	}
+	v.modifiedAt = now
+}
+
+// Get returns the current version and when the catalog last changed
+func (v *CatalogVersion) Get() (string, time.Time) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	return strconv.FormatInt(v.modifiedAt.UnixMilli(), 10), v.modifiedAt
+}
@@ -1,28 +1,44 @@
 package utils
 
 import (
+	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 // CorsMiddleware sets the necessary headers to support Cross-Origin Resource Sharing (CORS).
-func CorsMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Define allowed origins and headers
-		allowedOrigins := "*"
-		allowedMethods := []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-		allowedHeaders := []string{"Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization"}
+// Origins in allowedOrigins may send credentials, such as the auth cookie, and have
+// their origin echoed back. With no allowed origins any origin may call the gateway,
+// but browsers will not send credentials, as they refuse them for a wildcard origin.
+// Register it first, so preflight requests are answered before other checks.
+func CorsMiddleware(allowedOrigins []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[strings.TrimSuffix(origin, "/")] = true
+	}
+	allowedMethods := []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	allowedHeaders := []string{"Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "X-Client-Version", "X-Client-Platform", "X-Request-ID", "X-Nonce", "If-None-Match", "If-Match", "X-Tenant-ID"}
 
+	return func(c *gin.Context) {
 		// Set headers
-		c.Writer.Header().Set("Access-Control-Allow-Origin", allowedOrigins)
-		c.Writer.Header().Set("Access-Control-Allow-Methods", strings.Join(allowedMethods, ", "))
-		c.Writer.Header().Set("Access-Control-Allow-Headers", strings.Join(allowedHeaders, ", "))
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		header := c.Writer.Header()
+		if len(allowed) == 0 {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Add("Vary", "Origin")
+			if origin := c.GetHeader("Origin"); allowed[origin] {
+				header.Set("Access-Control-Allow-Origin", origin)
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+		header.Set("Access-Control-Allow-Methods", strings.Join(allowedMethods, ", "))
+		header.Set("Access-Control-Allow-Headers", strings.Join(allowedHeaders, ", "))
+		header.Set("Access-Control-Expose-Headers", "X-Request-ID, Location, ETag, Deprecation, Sunset, X-Pagination-Clamped")
 
 		// Handle preflight requests
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204) // No Content status code
+		if c.Request.Method == http.MethodOptions {
+			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
 
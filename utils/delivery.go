@@ -0,0 +1,34 @@
+package utils
+
+import "time"
+
+// averageMinutesPerKm is the assumed delivery travel pace
+const averageMinutesPerKm = 3
+
+// EstimateDistanceKm approximates the road distance between two Indian pincodes.
+// Addresses carry no coordinates, so the estimate is based on how many leading
+// digits the pincodes share: the more they share, the closer the areas are.
+func EstimateDistanceKm(fromPincode, toPincode string) float64 {
+	shared := 0
+	for shared < len(fromPincode) && shared < len(toPincode) && fromPincode[shared] == toPincode[shared] {
+		shared++
+	}
+
+	switch {
+	case shared >= 6:
+		return 2
+	case shared >= 4:
+		return 5
+	case shared >= 3:
+		return 10
+	case shared >= 2:
+		return 25
+	default:
+		return 50
+	}
+}
+
+// EstimateTravelTime returns the expected travel time for a delivery of distanceKm
+func EstimateTravelTime(distanceKm float64) time.Duration {
+	return time.Duration(distanceKm*averageMinutesPerKm) * time.Minute
+}
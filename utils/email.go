@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EmailDomainDenyList holds email domains that may not be used to sign up
+type EmailDomainDenyList map[string]bool
+
+// LoadEmailDomainDenyList builds a deny-list from the given domains plus, when
+// path is set, a file with one domain per line. Blank lines and lines starting
+// with # are ignored.
+func LoadEmailDomainDenyList(domains []string, path string) (EmailDomainDenyList, error) {
+	denyList := make(EmailDomainDenyList)
+	for _, domain := range domains {
+		denyList.add(domain)
+	}
+
+	if path == "" {
+		return denyList, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return denyList, fmt.Errorf("failed to open email domain deny-list: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		denyList.add(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return denyList, fmt.Errorf("failed to read email domain deny-list: %w", err)
+	}
+	return denyList, nil
+}
+
+func (d EmailDomainDenyList) add(domain string) {
+	if domain = strings.ToLower(strings.TrimSpace(domain)); domain != "" {
+		d[domain] = true
+	}
+}
+
+// Blocked reports whether the email's domain, or any parent domain of it, is
+// on the deny-list. An empty deny-list allows every domain.
+func (d EmailDomainDenyList) Blocked(email string) bool {
+	if len(d) == 0 {
+		return false
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+
+	domain := strings.ToLower(email[at+1:])
+	for domain != "" {
+		if d[domain] {
+			return true
+		}
+		_, parent, ok := strings.Cut(domain, ".")
+		if !ok {
+			break
+		}
+		domain = parent
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package utils
+
+import "sync"
+
+// FanOut calls fn for every index in [0, n) concurrently, with at most limit
+// calls in flight, and waits for all of them. A limit of zero or less means no bound.
+func FanOut(n, limit int, fn func(i int)) {
+	if limit <= 0 || limit > n {
+		limit = n
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, limit)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
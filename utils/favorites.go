@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// FavoritesStore persists the restaurants each user has marked as favorite.
+// None of the backend services expose favorites yet, so the gateway keeps them in
+// a store shared by all replicas until the user service has a favorites RPC.
+type FavoritesStore interface {
+	Add(userID, restaurantID string) error
+	Remove(userID, restaurantID string) error
+	List(userID string) ([]string, error)
+}
+
+// RedisFavoritesStore keeps each user's favorites in a Redis sorted set scored by
+// the time they were added
+type RedisFavoritesStore struct {
+	client *RedisClient
+}
+
+// NewRedisFavoritesStore creates a favorites store backed by client
+func NewRedisFavoritesStore(client *RedisClient) *RedisFavoritesStore {
+	return &RedisFavoritesStore{client: client}
+}
+
+func favoritesKey(userID string) string {
+	return "favorites:" + userID
+}
+
+// Add marks the restaurant as a favorite of the user. Adding an existing favorite is a no-op.
+func (s *RedisFavoritesStore) Add(userID, restaurantID string) error {
+	_, err := s.client.Int("ZADD", favoritesKey(userID), "NX", strconv.FormatInt(time.Now().UnixMilli(), 10), restaurantID)
+	return err
+}
+
+// Remove unmarks the restaurant as a favorite of the user
+func (s *RedisFavoritesStore) Remove(userID, restaurantID string) error {
+	_, err := s.client.Int("ZREM", favoritesKey(userID), restaurantID)
+	return err
+}
+
+// List returns the user's favorite restaurant IDs, most recently added first
+func (s *RedisFavoritesStore) List(userID string) ([]string, error) {
+	reply, err := s.client.Do("ZREVRANGE", favoritesKey(userID), "0", "-1")
+	if err != nil {
+		return nil, err
+	}
+	members, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Redis reply %v to ZREVRANGE", reply)
+	}
+
+	restaurantIDs := make([]string, 0, len(members))
+	for _, member := range members {
+		restaurantID, ok := member.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected Redis reply %v to ZREVRANGE", member)
+		}
+		restaurantIDs = append(restaurantIDs, restaurantID)
+	}
+	return restaurantIDs, nil
+}
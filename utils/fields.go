@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FieldsQueryParam is the query parameter clients use to select response fields
+const FieldsQueryParam = "fields"
+
+// ParseFields returns the comma separated field names from the fields query
+// parameter. An empty result means the full response was requested.
+func ParseFields(c *gin.Context) []string {
+	var fields []string
+	for _, field := range strings.Split(c.Query(FieldsQueryParam), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// ProjectFields reduces value to the requested top level JSON fields. Slices are
+// projected element by element. Requested fields that are not part of value's JSON
+// schema are ignored, and value is returned unchanged when none of them are known.
+func ProjectFields(value interface{}, fields []string) (interface{}, error) {
+	known := jsonFieldNames(reflect.TypeOf(value))
+	selected := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if known[field] {
+			selected[field] = true
+		}
+	}
+	if len(selected) == 0 {
+		return value, nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+
+	switch v := decoded.(type) {
+	case map[string]interface{}:
+		return projectObject(v, selected), nil
+	case []interface{}:
+		for i, element := range v {
+			if object, ok := element.(map[string]interface{}); ok {
+				v[i] = projectObject(object, selected)
+			}
+		}
+		return v, nil
+	default:
+		return value, nil
+	}
+}
+
+func projectObject(object map[string]interface{}, selected map[string]bool) map[string]interface{} {
+	for key := range object {
+		if !selected[key] {
+			delete(object, key)
+		}
+	}
+	return object
+}
+
+// jsonFieldNames returns the JSON names of the struct fields of t, looking
+// through pointers and slices
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	names := make(map[string]bool)
+	if t == nil || t.Kind() != reflect.Struct {
+		return names
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
+}
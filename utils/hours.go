@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OperatingHours is the daily window during which a restaurant accepts orders,
+// as offsets from midnight. A window whose close is before its open runs past
+// midnight, e.g. 18:00-02:00.
+type OperatingHours struct {
+	Open  time.Duration
+	Close time.Duration
+}
+
+// ParseOperatingHours parses a window such as "09:00-22:00"
+func ParseOperatingHours(value string) (OperatingHours, error) {
+	open, close, ok := strings.Cut(value, "-")
+	if !ok {
+		return OperatingHours{}, fmt.Errorf("operating hours %q must look like 09:00-22:00", value)
+	}
+
+	openAt, err := time.Parse("15:04", strings.TrimSpace(open))
+	if err != nil {
+		return OperatingHours{}, fmt.Errorf("invalid opening time in %q: %w", value, err)
+	}
+	closeAt, err := time.Parse("15:04", strings.TrimSpace(close))
+	if err != nil {
+		return OperatingHours{}, fmt.Errorf("invalid closing time in %q: %w", value, err)
+	}
+
+	return OperatingHours{
+		Open:  time.Duration(openAt.Hour())*time.Hour + time.Duration(openAt.Minute())*time.Minute,
+		Close: time.Duration(closeAt.Hour())*time.Hour + time.Duration(closeAt.Minute())*time.Minute,
+	}, nil
+}
+
+// Contains reports whether t, in its own location, falls within the window
+func (h OperatingHours) Contains(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if h.Open <= h.Close {
+		return offset >= h.Open && offset < h.Close
+	}
+	return offset >= h.Open || offset < h.Close
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	config "github.com/liju-github/FoodBuddyAPIGateway/configs"
+	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
 )
 
 // GetJWTClaim extracts the email and role claims from the JWT token in the Authorization cookie.
@@ -17,18 +18,14 @@ func GetJWTClaim(c *gin.Context) (email string, role string, err error) {
 		return "", "", errors.New("authorization token not found")
 	}
 
-	// Load the secret key
-	hmacSecretString := config.LoadConfig().JWTSecretKey
-	hmacSecret := []byte(hmacSecretString)
+	// Load the signing keys
+	keys, err := middleware.KeyRingFromConfig(config.LoadConfig())
+	if err != nil {
+		return "", "", err
+	}
 
 	// Parse and validate the token
-	token, err := jwt.Parse(JWTToken, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return hmacSecret, nil
-	})
+	token, err := jwt.Parse(JWTToken, keys.Keyfunc)
 	if err != nil {
 		return "", "", errors.New("invalid or malformed token")
 	}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/liju-github/FoodBuddyAPIGateway/model"
+)
+
+// MenuCSVHeader is the header row of a menu CSV export
+var MenuCSVHeader = []string{"name", "description", "category", "price", "stock"}
+
+// menuCSVFlushEvery is how many rows are buffered before they are flushed to the client
+const menuCSVFlushEvery = 100
+
+// WriteMenuCSV writes products as CSV to w, header first, flushing every
+// menuCSVFlushEvery rows so large menus are streamed rather than buffered
+func WriteMenuCSV(w io.Writer, products []model.MenuProduct) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(MenuCSVHeader); err != nil {
+		return err
+	}
+	for i, product := range products {
+		row := []string{
+			product.Name,
+			product.Description,
+			product.Category,
+			strconv.FormatFloat(product.Price, 'f', -1, 64),
+			strconv.FormatInt(int64(product.Stock), 10),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+		if (i+1)%menuCSVFlushEvery == 0 {
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// MenuCSVRow is one parsed data row of a menu CSV. Err is set when the row could not
+// be parsed, in which case Product holds whatever was read.
+type MenuCSVRow struct {
+	Product model.MenuProduct
+	Err     error
+}
+
+// ReadMenuCSV parses a menu CSV as written by WriteMenuCSV. Columns are matched by
+// header name, so they may be reordered and unknown columns are ignored, but name,
+// price and stock are required. At most maxRows data rows are read.
+func ReadMenuCSV(r io.Reader, maxRows int) ([]MenuCSVRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"name", "price", "stock"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV header is missing the %s column", required)
+		}
+	}
+
+	var rows []MenuCSVRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == maxRows {
+			return nil, fmt.Errorf("CSV has more than %d rows", maxRows)
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		row := MenuCSVRow{Product: model.MenuProduct{
+			Name:        field("name"),
+			Description: field("description"),
+			Category:    field("category"),
+		}}
+		if row.Product.Price, err = strconv.ParseFloat(field("price"), 64); err != nil {
+			row.Err = fmt.Errorf("invalid price %q", field("price"))
+		} else if stock, err := strconv.ParseInt(field("stock"), 10, 32); err != nil {
+			row.Err = fmt.Errorf("invalid stock %q", field("stock"))
+		} else {
+			row.Product.Stock = int32(stock)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
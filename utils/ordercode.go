@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OrderCodeLength is the number of characters in an order code
+const OrderCodeLength = 6
+
+// orderCodeAlphabet leaves out 0, O, 1 and I, which are easily confused when a code
+// is read out over the phone. Its 32 characters keep byte%32 unbiased.
+const orderCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// maxOrderCodeAttempts bounds the retries after a generated code collides
+const maxOrderCodeAttempts = 10
+
+// OrderCode is a short code assigned to an order at placement
+type OrderCode struct {
+	Code       string
+	OrderID    string
+	UserID     string
+	AssignedAt time.Time
+}
+
+// OrderCodeStore maps short, human friendly codes to order IDs for support
+// interactions. The order service has no such codes, so the gateway assigns them
+// when an order is placed.
+type OrderCodeStore interface {
+	// Assign returns the order's code, generating one if it has none yet
+	Assign(orderID, userID string) (string, error)
+	// Lookup returns the order a code was assigned to
+	Lookup(code string) (OrderCode, bool, error)
+}
+
+// NormalizeOrderCode uppercases code and strips the spaces and dashes people add
+// when reading or typing it
+func NormalizeOrderCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code)))
+}
+
+// InMemoryOrderCodeStore keeps order codes in process memory. Codes expire after
+// ttl so the store stays small; a zero ttl keeps them forever.
+type InMemoryOrderCodeStore struct {
+	mutex     sync.Mutex
+	ttl       time.Duration
+	codes     map[string]OrderCode
+	byOrder   map[string]string
+	lastSweep time.Time
+}
+
+// NewInMemoryOrderCodeStore creates an empty in-memory order code store
+func NewInMemoryOrderCodeStore(ttl time.Duration) *InMemoryOrderCodeStore {
+	return &InMemoryOrderCodeStore{
+		ttl:       ttl,
+		codes:     make(map[string]OrderCode),
+		byOrder:   make(map[string]string),
+		lastSweep: time.Now(),
+	}
+}
+
+// Assign returns the order's code, generating a new unique one if it has none
+func (s *InMemoryOrderCodeStore) Assign(orderID, userID string) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.sweep()
+	if code, ok := s.byOrder[orderID]; ok {
+		return code, nil
+	}
+
+	for attempt := 0; attempt < maxOrderCodeAttempts; attempt++ {
+		code, err := generateOrderCode()
+		if err != nil {
+			return "", err
+		}
+		if _, taken := s.codes[code]; taken {
+			continue
+		}
+		s.codes[code] = OrderCode{Code: code, OrderID: orderID, UserID: userID, AssignedAt: time.Now()}
+		s.byOrder[orderID] = code
+		return code, nil
+	}
+	return "", errors.New("no free order code found")
+}
+
+// Lookup returns the order a code was assigned to, if it has not expired
+func (s *InMemoryOrderCodeStore) Lookup(code string) (OrderCode, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, ok := s.codes[code]
+	if !ok || s.expired(entry) {
+		return OrderCode{}, false, nil
+	}
+	return entry, true, nil
+}
+
+func (s *InMemoryOrderCodeStore) expired(entry OrderCode) bool {
+	return s.ttl > 0 && time.Since(entry.AssignedAt) > s.ttl
+}
+
+// sweep drops expired codes, at most once an hour. The caller must hold the mutex.
+func (s *InMemoryOrderCodeStore) sweep() {
+	if s.ttl <= 0 || time.Since(s.lastSweep) < time.Hour {
+		return
+	}
+	s.lastSweep = time.Now()
+	for code, entry := range s.codes {
+		if s.expired(entry) {
+			delete(s.codes, code)
+			delete(s.byOrder, entry.OrderID)
+		}
+	}
+}
+
+func generateOrderCode() (string, error) {
+	buf := make([]byte, OrderCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = orderCodeAlphabet[int(b)%len(orderCodeAlphabet)]
+	}
+	return string(buf), nil
+}
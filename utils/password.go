@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicy describes the complexity rules new passwords must satisfy
+type PasswordPolicy struct {
+	MinLength        int
+	MaxLength        int
+	RequireUppercase bool
+	RequireDigit     bool
+	RequireSpecial   bool
+}
+
+// Validate checks password against every rule and returns an error listing each
+// requirement it failed, or nil when it satisfies the policy
+func (p PasswordPolicy) Validate(password string) error {
+	failed := p.lengthFailures(password)
+
+	var hasUpper, hasDigit, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSpecial = true
+		}
+	}
+	if p.RequireUppercase && !hasUpper {
+		failed = append(failed, "an uppercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		failed = append(failed, "a digit")
+	}
+	if p.RequireSpecial && !hasSpecial {
+		failed = append(failed, "a special character")
+	}
+
+	return passwordError(failed)
+}
+
+// ValidateLength checks only the length bounds. Login uses it so that tightening
+// the complexity rules does not lock out accounts created under older rules.
+func (p PasswordPolicy) ValidateLength(password string) error {
+	return passwordError(p.lengthFailures(password))
+}
+
+func (p PasswordPolicy) lengthFailures(password string) []string {
+	var failed []string
+	length := len([]rune(password))
+	if p.MinLength > 0 && length < p.MinLength {
+		failed = append(failed, fmt.Sprintf("at least %d characters", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		failed = append(failed, fmt.Sprintf("at most %d characters", p.MaxLength))
+	}
+	return failed
+}
+
+func passwordError(failed []string) error {
+	if len(failed) == 0 {
+		return nil
+	}
+	return errors.New("password must contain " + strings.Join(failed, ", "))
+}
@@ -2,22 +2,121 @@ package utils
 
 import (
 	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	config "github.com/liju-github/FoodBuddyAPIGateway/configs"
+	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
+	"github.com/sirupsen/logrus"
 )
 
-// RateLimitMiddleware creates a rate limiter with a max of 3 requests per IP per minute.
-func RateLimitMiddleware() gin.HandlerFunc {
-	const apiRate = 3
-	const resetInterval = time.Minute
-	const ttl = 3 * time.Minute // IPs inactive for longer than ttl are removed
+// RateLimitWarningHeader is set on responses to callers nearing their rate limit
+const RateLimitWarningHeader = "X-RateLimit-Warning"
 
+// warnNearRateLimit sets RateLimitWarningHeader once requests reaches ratio of
+// limit. Must be called before the handler writes the response.
+func warnNearRateLimit(c *gin.Context, requests, limit int, ratio float64) {
+	if ratio > 0 && float64(requests) >= ratio*float64(limit) {
+		c.Header(RateLimitWarningHeader, "true")
+	}
+}
+
+// RateLimitConfig sets the per-IP limit applied by RateLimitMiddleware: at most
+// Requests requests per Window. The in-memory limiter forgets IPs inactive for TTL.
+// Limiters sharing Redis need distinct Scopes to keep separate counters. Callers
+// reaching WarnRatio of the limit are warned, and a zero WarnRatio never warns.
+type RateLimitConfig struct {
+	Requests  int
+	Window    time.Duration
+	TTL       time.Duration
+	Scope     string
+	WarnRatio float64
+}
+
+// RateLimitConfigFrom returns the per-IP limit set in cfg
+func RateLimitConfigFrom(cfg config.Config) RateLimitConfig {
+	return RateLimitConfig{
+		Requests:  cfg.RateLimitRequests,
+		Window:    cfg.RateLimitWindow,
+		TTL:       cfg.RateLimitTTL,
+		WarnRatio: cfg.RateLimitWarnRatio,
+	}
+}
+
+// Rate limiter backends, selected by RATELIMITBACKEND
+const (
+	RateLimitBackendMemory = "memory"
+	RateLimitBackendRedis  = "redis"
+)
+
+// RateLimitMiddleware creates a rate limiter allowing limits.Requests requests per
+// IP in each limits.Window. Callers nearing the limit are warned with X-RateLimit-Warning.
+//
+// Counters are kept in process memory unless the Redis backend is configured, which
+// shares them between gateway replicas.
+func RateLimitMiddleware(cfg config.Config, limits RateLimitConfig) gin.HandlerFunc {
+	if cfg.RateLimitBackend == RateLimitBackendRedis {
+		client, err := NewRedisClient(cfg.RedisURL, cfg.RedisTimeout)
+		if err == nil {
+			return RedisRateLimitMiddleware(client, limits)
+		}
+		log.Printf("Invalid Redis URL for the rate limiter, falling back to in-memory counters: %v", err)
+	} else if cfg.RateLimitBackend != RateLimitBackendMemory {
+		log.Printf("Unknown rate limit backend %q, using in-memory counters", cfg.RateLimitBackend)
+	}
+	return inMemoryRateLimitMiddleware(limits)
+}
+
+// RedisRateLimitMiddleware is RateLimitMiddleware with counters kept in Redis, so
+// the limit holds across gateway replicas. Each IP gets one counter per window,
+// created by INCR and expired after the window. Requests are let through when Redis
+// cannot be reached, as throttling is not worth an outage.
+func RedisRateLimitMiddleware(client *RedisClient, limits RateLimitConfig) gin.HandlerFunc {
+	window := max(limits.Window, time.Second)
+	keyPrefix := "ratelimit"
+	if limits.Scope != "" {
+		keyPrefix += ":" + limits.Scope
+	}
+
+	return func(c *gin.Context) {
+		visitorIP := c.ClientIP()
+		key := fmt.Sprintf("%s:ip:%s:%d", keyPrefix, visitorIP, time.Now().UnixNano()/int64(window))
+
+		requests, err := client.Int("INCR", key)
+		if err != nil {
+			middleware.RequestLogger(c, logrus.StandardLogger()).WithError(err).Error("Rate limiter could not reach Redis, allowing request")
+			c.Next()
+			return
+		}
+		if requests == 1 {
+			// The window is part of the key, so a failed expire only leaves a stale key
+			if _, err := client.Int("EXPIRE", key, strconv.Itoa(int(math.Ceil(2*window.Seconds())))); err != nil {
+				middleware.RequestLogger(c, logrus.StandardLogger()).WithError(err).Warn("Failed to set rate limit counter expiry")
+			}
+		}
+
+		if requests > int64(limits.Requests) {
+			abortRateLimited(c, visitorIP)
+			return
+		}
+
+		warnNearRateLimit(c, int(requests), limits.Requests, limits.WarnRatio)
+		c.Next()
+	}
+}
+
+// inMemoryRateLimitMiddleware is RateLimitMiddleware with counters in process memory.
+// Each IP's window starts with its first request.
+func inMemoryRateLimitMiddleware(limits RateLimitConfig) gin.HandlerFunc {
 	type Visitor struct {
-		requests int
-		lastSeen time.Time
+		requests    int
+		windowStart time.Time
+		lastSeen    time.Time
 	}
 
 	var (
@@ -32,7 +131,7 @@ func RateLimitMiddleware() gin.HandlerFunc {
 		for range ticker.C {
 			mutex.Lock()
 			for ip, visitor := range visitors {
-				if time.Since(visitor.lastSeen) > ttl {
+				if time.Since(visitor.lastSeen) > max(limits.TTL, limits.Window) {
 					delete(visitors, ip)
 				}
 			}
@@ -45,41 +144,208 @@ func RateLimitMiddleware() gin.HandlerFunc {
 
 		// Check and update visitor data
 		mutex.Lock()
+		now := time.Now()
 		visitorData, exists := visitors[visitorIP]
-		if !exists {
-			visitorData = &Visitor{
-				requests: 1,
-				lastSeen: time.Now(),
-			}
+		if !exists || now.Sub(visitorData.windowStart) >= limits.Window {
+			visitorData = &Visitor{windowStart: now}
 			visitors[visitorIP] = visitorData
-		} else {
-			visitorData.requests++
-			visitorData.lastSeen = time.Now()
 		}
+		visitorData.requests++
+		visitorData.lastSeen = now
 		requests := visitorData.requests
 		mutex.Unlock()
 
 		// If rate limit exceeded, return 429 response
-		if requests > apiRate {
-			message := fmt.Sprintf("rate limit exceeded for IP: %v", visitorIP)
+		if requests > limits.Requests {
+			abortRateLimited(c, visitorIP)
+			return
+		}
+
+		warnNearRateLimit(c, requests, limits.Requests, limits.WarnRatio)
+		c.Next()
+	}
+}
+
+func abortRateLimited(c *gin.Context, visitorIP string) {
+	message := fmt.Sprintf("rate limit exceeded for IP: %v", visitorIP)
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"status":     false,
+		"message":    message,
+		"error_code": http.StatusTooManyRequests,
+	})
+}
+
+// entityCounter counts an entity's requests in a window that starts with its first
+// request
+type entityCounter interface {
+	// incr counts a request for key, returning the count and the time left in the window
+	incr(key string) (int64, time.Duration, error)
+	// refund takes back a request counted by incr
+	refund(key string) error
+}
+
+// EntityRateLimitMiddleware limits each authenticated entity to limits.Requests
+// successful requests per limits.Window, with counters in process memory. Requests
+// without an entity ID in context are keyed by client IP instead.
+//
+// A request is counted before the handler runs, so concurrent requests cannot exceed
+// the limit, and refunded unless the handler answers 2xx, so failures do not use up
+// the quota.
+func EntityRateLimitMiddleware(limits RateLimitConfig) gin.HandlerFunc {
+	return entityRateLimitMiddleware(newMemoryEntityCounter(limits.Window), limits)
+}
+
+// RedisEntityRateLimitMiddleware is EntityRateLimitMiddleware with counters kept in
+// Redis, so the limit holds across gateway replicas. Requests are let through when
+// Redis cannot be reached.
+func RedisEntityRateLimitMiddleware(client *RedisClient, limits RateLimitConfig) gin.HandlerFunc {
+	keyPrefix := "ratelimit"
+	if limits.Scope != "" {
+		keyPrefix += ":" + limits.Scope
+	}
+	return entityRateLimitMiddleware(&redisEntityCounter{client: client, keyPrefix: keyPrefix, window: limits.Window}, limits)
+}
+
+func entityRateLimitMiddleware(counter entityCounter, limits RateLimitConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, exists := middleware.GetEntityID(c)
+		if !exists || key == "" {
+			key = c.ClientIP()
+		}
+		logger := middleware.RequestLogger(c, logrus.StandardLogger())
+
+		requests, retryAfter, err := counter.incr(key)
+		if err != nil {
+			logger.WithError(err).Error("Rate limiter could not count request, allowing it")
+			c.Next()
+			return
+		}
+
+		if requests > int64(limits.Requests) {
+			if err := counter.refund(key); err != nil {
+				logger.WithError(err).Warn("Failed to refund rate limited request")
+			}
+			c.Header("Retry-After", fmt.Sprintf("%d", int(retryAfter.Seconds())))
 			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
 				"status":     false,
-				"message":    message,
+				"message":    fmt.Sprintf("rate limit exceeded, try again in %s", retryAfter.Round(time.Second)),
 				"error_code": http.StatusTooManyRequests,
 			})
 			return
 		}
 
+		warnNearRateLimit(c, int(requests), limits.Requests, limits.WarnRatio)
 		c.Next()
 
-		// Reset visitor requests every minute
-		go func() {
-			time.Sleep(resetInterval)
-			mutex.Lock()
-			if visitor, ok := visitors[visitorIP]; ok && visitor.requests > 0 {
-				visitor.requests = 0
+		if status := c.Writer.Status(); status < 200 || status > 299 {
+			if err := counter.refund(key); err != nil {
+				logger.WithError(err).Warn("Failed to refund failed request")
 			}
-			mutex.Unlock()
-		}()
+		}
+	}
+}
+
+// memoryEntityCounter is an entityCounter in process memory
+type memoryEntityCounter struct {
+	mutex   sync.Mutex
+	window  time.Duration
+	entries map[string]*entityWindow
+}
+
+type entityWindow struct {
+	requests    int64
+	windowStart time.Time
+}
+
+func newMemoryEntityCounter(window time.Duration) *memoryEntityCounter {
+	counter := &memoryEntityCounter{
+		window:  window,
+		entries: make(map[string]*entityWindow),
+	}
+
+	// Background cleanup for expired windows
+	go func() {
+		ticker := time.NewTicker(window)
+		defer ticker.Stop()
+		for range ticker.C {
+			counter.mutex.Lock()
+			for key, e := range counter.entries {
+				if time.Since(e.windowStart) > window {
+					delete(counter.entries, key)
+				}
+			}
+			counter.mutex.Unlock()
+		}
+	}()
+
+	return counter
+}
+
+func (m *memoryEntityCounter) incr(key string) (int64, time.Duration, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok || time.Since(e.windowStart) > m.window {
+		e = &entityWindow{windowStart: time.Now()}
+		m.entries[key] = e
+	}
+	e.requests++
+	return e.requests, m.window - time.Since(e.windowStart), nil
+}
+
+func (m *memoryEntityCounter) refund(key string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if e, ok := m.entries[key]; ok && e.requests > 0 {
+		e.requests--
+	}
+	return nil
+}
+
+// redisEntityCounter is an entityCounter in Redis. Each entity's counter expires
+// one window after its first request.
+type redisEntityCounter struct {
+	client    *RedisClient
+	keyPrefix string
+	window    time.Duration
+}
+
+func (r *redisEntityCounter) key(entity string) string {
+	return fmt.Sprintf("%s:entity:%s", r.keyPrefix, entity)
+}
+
+func (r *redisEntityCounter) incr(entity string) (int64, time.Duration, error) {
+	key := r.key(entity)
+	requests, err := r.client.Int("INCR", key)
+	if err != nil {
+		return 0, 0, err
+	}
+	// The expiry is set by whichever request finds it missing, so a counter whose
+	// first PEXPIRE failed still ends
+	ttl, err := r.client.Int("PTTL", key)
+	if err != nil {
+		return 0, 0, err
+	}
+	if ttl < 0 {
+		if _, err := r.client.Int("PEXPIRE", key, strconv.FormatInt(r.window.Milliseconds(), 10)); err != nil {
+			return 0, 0, err
+		}
+		ttl = r.window.Milliseconds()
+	}
+	return requests, time.Duration(ttl) * time.Millisecond, nil
+}
+
+func (r *redisEntityCounter) refund(entity string) error {
+	key := r.key(entity)
+	requests, err := r.client.Int("DECR", key)
+	if err != nil {
+		return err
+	}
+	// A counter that expired in the meantime was recreated without an expiry
+	if requests <= 0 {
+		_, err = r.client.Int("DEL", key)
 	}
+	return err
 }
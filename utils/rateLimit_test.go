@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestInMemoryRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		requests int
+	}{
+		{name: "one request per window", requests: 1},
+		{name: "three requests per window", requests: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limits := RateLimitConfig{Requests: tt.requests, Window: 50 * time.Millisecond, TTL: time.Minute}
+			router := gin.New()
+			router.Use(inMemoryRateLimitMiddleware(limits))
+			router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			get := func() int {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.RemoteAddr = "203.0.113.1:1234"
+				recorder := httptest.NewRecorder()
+				router.ServeHTTP(recorder, req)
+				return recorder.Code
+			}
+
+			for i := 0; i < tt.requests; i++ {
+				if code := get(); code != http.StatusOK {
+					t.Fatalf("request %d: got %d, want 200", i+1, code)
+				}
+			}
+			if code := get(); code != http.StatusTooManyRequests {
+				t.Fatalf("request %d: got %d, want 429", tt.requests+1, code)
+			}
+
+			// A new window starts with a fresh count
+			time.Sleep(limits.Window)
+			if code := get(); code != http.StatusOK {
+				t.Fatalf("after the window: got %d, want 200", code)
+			}
+		})
+	}
+}
+
+func TestEntityRateLimitCountsOnlySuccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	status := http.StatusInternalServerError
+	router := gin.New()
+	router.Use(EntityRateLimitMiddleware(RateLimitConfig{Requests: 1, Window: time.Hour}))
+	router.GET("/", func(c *gin.Context) { c.Status(status) })
+
+	get := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "203.0.113.1:1234"
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	// Failed requests leave the quota untouched
+	for i := 0; i < 3; i++ {
+		if code := get(); code != http.StatusInternalServerError {
+			t.Fatalf("failed request %d: got %d, want 500", i+1, code)
+		}
+	}
+
+	status = http.StatusOK
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("first successful request: got %d, want 200", code)
+	}
+	if code := get(); code != http.StatusTooManyRequests {
+		t.Fatalf("request after the quota is used: got %d, want 429", code)
+	}
+}
@@ -0,0 +1,31 @@
+package utils
+
+import "sync"
+
+// StockVersions counts the stock changes the gateway has applied to each product,
+// giving stock readings a version that never repeats, unlike the stock level itself.
+// Counts are kept in process memory and start over when the gateway restarts. It is
+// safe for concurrent use.
+type StockVersions struct {
+	mutex    sync.Mutex
+	versions map[string]uint64
+}
+
+// NewStockVersions creates a counter with every product at version 0
+func NewStockVersions() *StockVersions {
+	return &StockVersions{versions: make(map[string]uint64)}
+}
+
+// Bump records a change to the product's stock
+func (v *StockVersions) Bump(productID string) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.versions[productID]++
+}
+
+// Get returns the number of stock changes recorded for the product
+func (v *StockVersions) Get(productID string) uint64 {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	return v.versions[productID]
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/liju-github/FoodBuddyAPIGateway/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+var _ middleware.TokenBlacklist = (*RedisTokenBlacklist)(nil)
+
+// RedisTokenBlacklist keeps revoked token IDs in Redis, so a logout or a consumed
+// refresh token is honoured by every gateway replica. Each entry expires with its
+// token.
+//
+// When Redis cannot be reached, tokens are treated as not revoked, as access tokens
+// are short-lived and rejecting every request is worse, but single-use tokens are
+// treated as already used, so a refresh token cannot be replayed during an outage.
+type RedisTokenBlacklist struct {
+	client *RedisClient
+	logger *logrus.Logger
+}
+
+// NewRedisTokenBlacklist creates a blacklist backed by client
+func NewRedisTokenBlacklist(client *RedisClient, logger *logrus.Logger) *RedisTokenBlacklist {
+	return &RedisTokenBlacklist{client: client, logger: logger}
+}
+
+func revokedTokenKey(jti string) string {
+	return "revoked:" + jti
+}
+
+// untilExpiry returns the milliseconds left until expiresAt, at least one
+func untilExpiry(expiresAt time.Time) string {
+	return strconv.FormatInt(max(time.Until(expiresAt).Milliseconds(), 1), 10)
+}
+
+// Revoke rejects the token until expiresAt
+func (b *RedisTokenBlacklist) Revoke(jti string, expiresAt time.Time) {
+	if _, err := b.client.Do("SET", revokedTokenKey(jti), "1", "PX", untilExpiry(expiresAt)); err != nil {
+		b.logger.WithError(err).WithField("jti", jti).Error("Failed to revoke token in Redis")
+	}
+}
+
+// RevokeIfNew revokes the token until expiresAt, reporting false if it already was
+func (b *RedisTokenBlacklist) RevokeIfNew(jti string, expiresAt time.Time) bool {
+	reply, err := b.client.Do("SET", revokedTokenKey(jti), "1", "PX", untilExpiry(expiresAt), "NX")
+	if err != nil {
+		b.logger.WithError(err).WithField("jti", jti).Error("Failed to consume token in Redis, refusing it")
+		return false
+	}
+	// SET NX answers OK when it set the key and nil when the key existed
+	return reply != nil
+}
+
+// IsRevoked reports whether the token was revoked
+func (b *RedisTokenBlacklist) IsRevoked(jti string) bool {
+	exists, err := b.client.Int("EXISTS", revokedTokenKey(jti))
+	if err != nil {
+		b.logger.WithError(err).WithField("jti", jti).Error("Failed to check token revocation in Redis, allowing it")
+		return false
+	}
+	return exists > 0
+}
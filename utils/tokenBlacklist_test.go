@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fakeRedis serves the SET and EXISTS commands the token blacklist sends, without
+// expiring keys
+type fakeRedis struct {
+	mutex sync.Mutex
+	keys  map[string]string
+}
+
+func startFakeRedis(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &fakeRedis{keys: make(map[string]string)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return "redis://" + listener.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		fmt.Fprint(conn, f.reply(args))
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, count)
+	for i := range args {
+		if _, err := reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func (f *fakeRedis) reply(args []string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "SET":
+		_, exists := f.keys[args[1]]
+		if exists && strings.EqualFold(args[len(args)-1], "NX") {
+			return "$-1\r\n"
+		}
+		f.keys[args[1]] = args[2]
+		return "+OK\r\n"
+	case "EXISTS":
+		if _, exists := f.keys[args[1]]; exists {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+func TestRedisTokenBlacklist(t *testing.T) {
+	client, err := NewRedisClient(startFakeRedis(t), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blacklist := NewRedisTokenBlacklist(client, logrus.StandardLogger())
+	expiresAt := time.Now().Add(time.Hour)
+
+	if blacklist.IsRevoked("access-1") {
+		t.Fatal("token revoked before Revoke")
+	}
+	blacklist.Revoke("access-1", expiresAt)
+	if !blacklist.IsRevoked("access-1") {
+		t.Error("token not revoked after Revoke")
+	}
+
+	if !blacklist.RevokeIfNew("refresh-1", expiresAt) {
+		t.Fatal("first use of a refresh token refused")
+	}
+	if blacklist.RevokeIfNew("refresh-1", expiresAt) {
+		t.Error("second use of a refresh token allowed")
+	}
+	if !blacklist.IsRevoked("refresh-1") {
+		t.Error("refresh token not revoked after use")
+	}
+}
+
+func TestRedisTokenBlacklistUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	client, err := NewRedisClient("redis://"+addr, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blacklist := NewRedisTokenBlacklist(client, logrus.StandardLogger())
+
+	if blacklist.IsRevoked("access-1") {
+		t.Error("access token rejected while Redis is unreachable")
+	}
+	if blacklist.RevokeIfNew("refresh-1", time.Now().Add(time.Hour)) {
+		t.Error("refresh token accepted while Redis is unreachable")
+	}
+}
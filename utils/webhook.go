@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Order webhook event types
+const (
+	EventOrderPlaced    = "order.placed"
+	EventOrderConfirmed = "order.confirmed"
+	EventOrderCancelled = "order.cancelled"
+)
+
+// WebhookSignatureHeader carries the hex encoded HMAC-SHA256 of the request body,
+// keyed with the receiving restaurant's secret
+const WebhookSignatureHeader = "X-FoodBuddy-Signature"
+
+// WebhookEvent is the JSON payload delivered to restaurant webhooks
+type WebhookEvent struct {
+	Event        string      `json:"event"`
+	RestaurantID string      `json:"restaurantId"`
+	OrderID      string      `json:"orderId"`
+	Status       string      `json:"status,omitempty"`
+	Data         interface{} `json:"data,omitempty"`
+	Timestamp    int64       `json:"timestamp"`
+}
+
+// WebhookNotifier pushes signed order events to per-restaurant webhook URLs
+type WebhookNotifier struct {
+	webhooks   map[string]webhook
+	client     *http.Client
+	maxRetries int
+}
+
+// webhook is a restaurant's webhook URL and the secret its events are signed with
+type webhook struct {
+	url    string
+	secret []byte
+}
+
+// NewWebhookNotifier creates a notifier for the given restaurant ID to URL mapping.
+// Every restaurant with a URL needs its own non-empty secret in secrets, so that a
+// valid signature proves the event was meant for that restaurant.
+func NewWebhookNotifier(urls, secrets map[string]string, timeout time.Duration, maxRetries int) (*WebhookNotifier, error) {
+	webhooks := make(map[string]webhook, len(urls))
+	var missing []string
+	for restaurantID, url := range urls {
+		secret := secrets[restaurantID]
+		if secret == "" {
+			missing = append(missing, restaurantID)
+			continue
+		}
+		webhooks[restaurantID] = webhook{url: url, secret: []byte(secret)}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("no webhook secret configured for restaurants %s", strings.Join(missing, ", "))
+	}
+
+	return &WebhookNotifier{
+		webhooks:   webhooks,
+		client:     &http.Client{Timeout: timeout},
+		maxRetries: maxRetries,
+	}, nil
+}
+
+// Enabled reports whether any restaurant has a webhook configured
+func (n *WebhookNotifier) Enabled() bool {
+	return n != nil && len(n.webhooks) > 0
+}
+
+// HasWebhook reports whether a webhook is configured for the restaurant
+func (n *WebhookNotifier) HasWebhook(restaurantID string) bool {
+	if n == nil {
+		return false
+	}
+	_, ok := n.webhooks[restaurantID]
+	return ok
+}
+
+// Notify delivers the event in the background. Events for restaurants without a
+// configured webhook are dropped.
+func (n *WebhookNotifier) Notify(event WebhookEvent) {
+	if !n.HasWebhook(event.RestaurantID) {
+		return
+	}
+	if event.Timestamp == 0 {
+		event.Timestamp = time.Now().Unix()
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to encode webhook event %s for order %s: %v", event.Event, event.OrderID, err)
+		return
+	}
+
+	target := n.webhooks[event.RestaurantID]
+	go func() {
+		if err := n.deliver(target, body); err != nil {
+			log.Printf("Failed to deliver webhook event %s for order %s: %v", event.Event, event.OrderID, err)
+		}
+	}()
+}
+
+// deliver POSTs the body to the webhook, retrying with exponential backoff
+func (n *WebhookNotifier) deliver(target webhook, body []byte) error {
+	signature := SignPayload(target.secret, body)
+	backoff := 500 * time.Millisecond
+
+	var lastErr error
+	for attempt := 0; attempt <= n.maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		req, err := http.NewRequest(http.MethodPost, target.url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(WebhookSignatureHeader, signature)
+
+		resp, err := n.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return lastErr
+}
+
+// SignPayload returns the hex encoded HMAC-SHA256 of body using secret
+func SignPayload(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}